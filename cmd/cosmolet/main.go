@@ -15,17 +15,26 @@ import (
 	"cosmolet/pkg/config"
 	"cosmolet/pkg/controller"
 	"cosmolet/pkg/health"
+	"cosmolet/pkg/metrics"
 )
 
 const (
 	defaultConfigPath = "/etc/cosmolet/config.yaml"
 	defaultLogLevel   = "info"
+
+	// configPollInterval is how often the configuration file is checked for
+	// changes
+	configPollInterval = 10 * time.Second
+	// stopTimeout bounds how long the controller may take to release its
+	// Lease and stop the routing backend once the shutdown policy is applied
+	stopTimeout = 10 * time.Second
 )
 
 var (
 	configPath = flag.String("config", defaultConfigPath, "Path to configuration file")
 	logLevel   = flag.String("log-level", defaultLogLevel, "Log level (debug, info, warn, error)")
 	version    = flag.Bool("version", false, "Print version information")
+	watch      = flag.Bool("watch-config", true, "Reload the configuration file when it changes")
 
 	// Build information (set via ldflags)
 	Version   = "dev"
@@ -43,6 +52,7 @@ func main() {
 
 	log.Printf("Starting Cosmolet BGP Service Controller")
 	log.Printf("Version: %s, Commit: %s, Build Date: %s", Version, GitCommit, BuildDate)
+	metrics.SetBuildInfo(Version, GitCommit)
 
 	// Load configuration
 	cfg, err := config.LoadConfig(*configPath)
@@ -51,6 +61,7 @@ func main() {
 	}
 
 	log.Printf("Configuration loaded from: %s", *configPath)
+	watcher := config.NewWatcher(*configPath, configPollInterval)
 	log.Printf("Monitoring namespaces: %v", cfg.Services.Namespaces)
 	log.Printf("Loop interval: %d seconds", cfg.LoopIntervalSeconds)
 
@@ -63,26 +74,42 @@ func main() {
 	go startHealthServer(healthChecker)
 
 	// Create and start BGP controller
-	bgpController, err := controller.NewBGPServiceController(cfg, ctx)
+	bgpController, err := controller.NewBGPServiceController(cfg, ctx, healthChecker)
 	if err != nil {
 		log.Fatalf("Failed to create BGP service controller: %v", err)
 	}
 
-	// Start controller in goroutine
+	// Start controller in goroutine; it marks the checker ready after the
+	// first successful reconcile
+	stopped := make(chan struct{})
 	go func() {
+		defer close(stopped)
 		if err := bgpController.Start(); err != nil {
 			log.Printf("BGP controller error: %v", err)
+			healthChecker.SetLive(false)
 			cancel()
 		}
 	}()
 
-	// Mark as ready
-	healthChecker.SetReady(true)
+	// Apply configuration changes without restarting
+	if *watch {
+		go watcher.Run(ctx, bgpController.Reload)
+	}
 
-	// Wait for shutdown signal
-	waitForShutdown(cancel)
+	// Wait for shutdown signal, then apply the shutdown policy while the
+	// backend is still running
+	shutdownCtx := waitForShutdown()
 
 	log.Println("Shutting down Cosmolet BGP Service Controller")
+	bgpController.Shutdown(shutdownCtx)
+
+	// Start returns once the Lease is released and the backend stopped
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(stopTimeout):
+		log.Printf("Controller did not stop within %v, exiting", stopTimeout)
+	}
 }
 
 func printVersion() {
@@ -100,8 +127,8 @@ func startHealthServer(checker *health.Checker) {
 	mux.HandleFunc("/readyz", checker.ReadinessHandler)
 	mux.HandleFunc("/version", versionHandler)
 
-	// Metrics endpoint (basic for now)
-	mux.HandleFunc("/metrics", metricsHandler)
+	// Prometheus metrics
+	mux.Handle("/metrics", metrics.Handler())
 
 	server := &http.Server{
 		Addr:    ":8080",
@@ -123,23 +150,20 @@ func versionHandler(w http.ResponseWriter, r *http.Request) {
 	}`, Version, GitCommit, BuildDate)
 }
 
-func metricsHandler(w http.ResponseWriter, r *http.Request) {
-	// Basic metrics endpoint - in a real implementation,
-	// you would use Prometheus client library
-	w.Header().Set("Content-Type", "text/plain")
-	fmt.Fprintf(w, "# HELP cosmolet_info Information about cosmolet\n")
-	fmt.Fprintf(w, "# TYPE cosmolet_info gauge\n")
-	fmt.Fprintf(w, "cosmolet_info{version=\"%s\",commit=\"%s\"} 1\n", Version, GitCommit)
-}
-
-func waitForShutdown(cancel context.CancelFunc) {
-	sigChan := make(chan os.Signal, 1)
+// waitForShutdown blocks until SIGINT or SIGTERM and returns a context that
+// a second signal cancels, cutting a graceful shutdown short
+func waitForShutdown() context.Context {
+	sigChan := make(chan os.Signal, 2)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
 	sig := <-sigChan
 	log.Printf("Received signal: %s", sig)
 
-	// Give some time for graceful shutdown
-	cancel()
-	time.Sleep(5 * time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		sig := <-sigChan
+		log.Printf("Received signal: %s, shutting down immediately", sig)
+		cancel()
+	}()
+	return ctx
 }
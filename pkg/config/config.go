@@ -4,29 +4,139 @@ package config
 import (
 	"fmt"
 	"io/ioutil"
+	"net"
 	"os"
+	"reflect"
+
+	"cosmolet/pkg/route"
 
 	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 // Config represents the complete configuration structure
 type Config struct {
-	Services            ServicesConfig `yaml:"services"`
-	LoopIntervalSeconds int            `yaml:"loop_interval_seconds"`
-	BGP                 BGPConfig      `yaml:"bgp,omitempty"`
-	Logging             LoggingConfig  `yaml:"logging,omitempty"`
-	FRR                 FRRConfig      `yaml:"frr,omitempty"`
+	Services            ServicesConfig  `yaml:"services"`
+	LoopIntervalSeconds int             `yaml:"loop_interval_seconds"`
+	BGP                 BGPConfig       `yaml:"bgp,omitempty"`
+	Logging             LoggingConfig   `yaml:"logging,omitempty"`
+	FRR                 FRRConfig       `yaml:"frr,omitempty"`
+	Election            ElectionConfig  `yaml:"election,omitempty"`
+	Interface           InterfaceConfig `yaml:"interface,omitempty"`
+	CustomResources     CRDConfig       `yaml:"custom_resources,omitempty"`
+	Shutdown            ShutdownConfig  `yaml:"shutdown,omitempty"`
 }
 
+// Service address types that can be advertised
+const (
+	AddressTypeClusterIP      = "clusterip"
+	AddressTypeExternalIP     = "externalip"
+	AddressTypeLoadBalancer   = "loadbalancer"
+	AddressTypeLoadBalancerIP = "loadbalancerip"
+)
+
+// Service advertisement modes
+const (
+	// AdvertiseModeOptOut advertises every service unless it is annotated
+	// with cosmolet.io/advertise: "false"
+	AdvertiseModeOptOut = "opt-out"
+	// AdvertiseModeOptIn only advertises services annotated with
+	// cosmolet.io/advertise: "true"
+	AdvertiseModeOptIn = "opt-in"
+)
+
 // ServicesConfig contains service discovery configuration
 type ServicesConfig struct {
 	Namespaces []string `yaml:"namespaces"`
+	// AllNamespaces watches services in every namespace, ignoring
+	// Namespaces and NamespaceSelector
+	AllNamespaces bool `yaml:"all_namespaces,omitempty"`
+	// NamespaceSelector and ServiceSelector are label selectors in the usual
+	// Kubernetes syntax, e.g. "team=payments,env in (prod,staging)". A
+	// namespace selector replaces the Namespaces list, so that new matching
+	// namespaces are picked up without a restart.
+	NamespaceSelector string `yaml:"namespace_selector,omitempty"`
+	ServiceSelector   string `yaml:"service_selector,omitempty"`
+	// AddressTypes selects which service addresses are advertised: clusterip
+	// (spec.clusterIP), externalip (spec.externalIPs), loadbalancer
+	// (status.loadBalancer.ingress[].ip) and loadbalancerip (spec.loadBalancerIP)
+	AddressTypes []string `yaml:"address_types"`
+	// IncludeTerminating keeps a service advertised while its only remaining
+	// endpoints are terminating but still serving
+	IncludeTerminating bool `yaml:"include_terminating"`
+	// AdvertiseMode is the default for services without a
+	// cosmolet.io/advertise annotation: opt-out or opt-in
+	AdvertiseMode string `yaml:"advertise_mode,omitempty"`
 }
 
+// Route advertisement backends
+const (
+	BackendFRR   = "frr"
+	BackendGoBGP = "gobgp"
+)
+
 // BGPConfig contains BGP-specific configuration
 type BGPConfig struct {
 	Enabled bool `yaml:"enabled"`
 	ASN     int  `yaml:"asn,omitempty"`
+	// Backend selects how routes are announced: frr (via vtysh) or gobgp
+	// (an embedded BGP speaker)
+	Backend string `yaml:"backend,omitempty"`
+	// Managed makes cosmolet render "router bgp", the router-id and the
+	// peers into FRR instead of relying on an existing configuration. The
+	// gobgp backend is always managed.
+	Managed bool `yaml:"managed,omitempty"`
+	// RouterID, ListenPort and Peers configure the managed BGP instance. The
+	// router-id defaults to the node's IPv4 address. A ListenPort of -1
+	// disables passive connections in the gobgp backend.
+	RouterID   string       `yaml:"router_id,omitempty"`
+	ListenPort int          `yaml:"listen_port,omitempty"`
+	Peers      []PeerConfig `yaml:"peers,omitempty"`
+	// Communities and LargeCommunities are set on every service route unless
+	// a service overrides them with the cosmolet.io/bgp-communities and
+	// cosmolet.io/bgp-large-communities annotations
+	Communities      []string `yaml:"communities,omitempty"`
+	LargeCommunities []string `yaml:"large_communities,omitempty"`
+	// Path holds the default local-preference, MED and AS-path prepending
+	// for service routes
+	Path PathConfig `yaml:",inline"`
+	// Nodes overrides settings for individual nodes, keyed by node name
+	Nodes map[string]NodeConfig `yaml:"nodes,omitempty"`
+}
+
+// PathConfig sets BGP path attributes on service routes. Services can
+// override each value with the cosmolet.io/bgp-local-pref,
+// cosmolet.io/bgp-med and cosmolet.io/bgp-as-path-prepend annotations.
+type PathConfig struct {
+	LocalPref     *uint32 `yaml:"local_pref,omitempty"`
+	MED           *uint32 `yaml:"med,omitempty"`
+	ASPathPrepend int     `yaml:"as_path_prepend,omitempty"`
+}
+
+// NodeConfig holds the settings that override the global BGP
+// configuration on one node. Unset values keep the global setting; peers
+// replace the global list.
+type NodeConfig struct {
+	ASN      int          `yaml:"asn,omitempty"`
+	RouterID string       `yaml:"router_id,omitempty"`
+	Peers    []PeerConfig `yaml:"peers,omitempty"`
+	Path     PathConfig   `yaml:",inline"`
+}
+
+// PeerConfig describes a BGP neighbor
+type PeerConfig struct {
+	Address  string `yaml:"address"`
+	RemoteAS int    `yaml:"remote_as"`
+	Port     int    `yaml:"port,omitempty"`
+	Password string `yaml:"password,omitempty"`
+	// EBGPMultihop allows eBGP sessions to peers this many hops away
+	EBGPMultihop int `yaml:"ebgp_multihop,omitempty"`
+	// KeepaliveSeconds and HoldTimeSeconds override the session timers;
+	// both must be set together
+	KeepaliveSeconds int `yaml:"keepalive_seconds,omitempty"`
+	HoldTimeSeconds  int `yaml:"hold_time_seconds,omitempty"`
+	// BFD enables Bidirectional Forwarding Detection for the session
+	BFD bool `yaml:"bfd,omitempty"`
 }
 
 // LoggingConfig contains logging configuration
@@ -35,31 +145,81 @@ type LoggingConfig struct {
 	Format string `yaml:"format"`
 }
 
+// FRR persistence modes
+const (
+	// PersistenceRuntime only changes the running configuration
+	PersistenceRuntime = "runtime"
+	// PersistenceWriteMemory saves the running configuration to frr.conf
+	PersistenceWriteMemory = "write-memory"
+	// PersistenceIncludeFile keeps service routes in a file owned by cosmolet
+	PersistenceIncludeFile = "include-file"
+)
+
 // FRRConfig contains FRR-specific configuration
 type FRRConfig struct {
 	SocketPath string `yaml:"socket_path"`
 	ConfigPath string `yaml:"config_path,omitempty"`
+	// Persistence selects how service routes survive FRR restarts: runtime,
+	// write-memory or include-file
+	Persistence string `yaml:"persistence,omitempty"`
+	// IncludePath is the file cosmolet writes its network statements to in
+	// include-file mode, to be loaded with "vtysh -f" when FRR starts
+	IncludePath string `yaml:"include_path,omitempty"`
+}
+
+// InterfaceConfig selects the device service addresses are assigned to.
+// Any name other than lo is created as a dummy interface if missing, and
+// cosmolet owns every host address on the interfaces it created, which it
+// marks with an alias. On lo and other existing devices ownership cannot be
+// told apart, so orphaned addresses are not cleaned up there.
+type InterfaceConfig struct {
+	Name string `yaml:"name"`
+}
+
+// CRDConfig enables the BGPPeer and ServiceAdvertisementPolicy custom
+// resources, which extend this configuration. Their CRDs must be installed.
+type CRDConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// ElectionConfig contains leader election configuration. When enabled, only
+// the instance holding the Lease advertises routes; the others keep
+// monitoring services and reporting health. Addresses under a Local traffic
+// policy are advertised by every node with a local endpoint regardless.
+type ElectionConfig struct {
+	Enabled              bool   `yaml:"enabled"`
+	LeaseName            string `yaml:"lease_name"`
+	LeaseNamespace       string `yaml:"lease_namespace,omitempty"`
+	LeaseDurationSeconds int    `yaml:"lease_duration_seconds"`
+	RenewDeadlineSeconds int    `yaml:"renew_deadline_seconds"`
+	RetryPeriodSeconds   int    `yaml:"retry_period_seconds"`
+}
+
+// Shutdown policies for the routes advertised by a stopping instance
+const (
+	// ShutdownKeep leaves the routes in place for the next instance to
+	// adopt. Traffic keeps arriving while no instance is running, so it
+	// suits fast restarts only, and the gobgp backend cannot provide it.
+	ShutdownKeep = "keep"
+	// ShutdownWithdraw withdraws every route immediately
+	ShutdownWithdraw = "withdraw"
+	// ShutdownGraceful re-advertises the routes with the graceful-shutdown
+	// community (RFC 8326) so that peers move traffic away, then withdraws
+	// them after GracefulSeconds. This is the default.
+	ShutdownGraceful = "graceful"
+)
+
+// ShutdownConfig selects what happens to advertised routes when cosmolet
+// receives SIGTERM. The pod's terminationGracePeriodSeconds must leave time
+// for the graceful period.
+type ShutdownConfig struct {
+	Policy          string `yaml:"policy"`
+	GracefulSeconds int    `yaml:"graceful_seconds,omitempty"`
 }
 
 // LoadConfig loads configuration from the specified file path
 func LoadConfig(configPath string) (*Config, error) {
-	// Set defaults
-	config := &Config{
-		Services: ServicesConfig{
-			Namespaces: []string{"default"},
-		},
-		LoopIntervalSeconds: 30,
-		BGP: BGPConfig{
-			Enabled: true,
-		},
-		Logging: LoggingConfig{
-			Level:  "info",
-			Format: "text",
-		},
-		FRR: FRRConfig{
-			SocketPath: "/var/run/frr",
-		},
-	}
+	config := defaultConfig()
 
 	// Check if config file exists
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
@@ -73,6 +233,54 @@ func LoadConfig(configPath string) (*Config, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file %s: %v", configPath, err)
 	}
+	return parseConfig(configPath, data)
+}
+
+// defaultConfig returns the configuration used for unset values
+func defaultConfig() *Config {
+	return &Config{
+		Services: ServicesConfig{
+			Namespaces:         []string{"default"},
+			AddressTypes:       []string{AddressTypeClusterIP},
+			IncludeTerminating: true,
+			AdvertiseMode:      AdvertiseModeOptOut,
+		},
+		LoopIntervalSeconds: 30,
+		BGP: BGPConfig{
+			Enabled:    true,
+			Backend:    BackendFRR,
+			ListenPort: 179,
+		},
+		Logging: LoggingConfig{
+			Level:  "info",
+			Format: "text",
+		},
+		FRR: FRRConfig{
+			SocketPath:  "/var/run/frr",
+			Persistence: PersistenceWriteMemory,
+			IncludePath: "/etc/frr/cosmolet.conf",
+		},
+		Interface: InterfaceConfig{
+			Name: "cosmolet0",
+		},
+		Election: ElectionConfig{
+			Enabled:              false,
+			LeaseName:            "cosmolet",
+			LeaseDurationSeconds: 15,
+			RenewDeadlineSeconds: 10,
+			RetryPeriodSeconds:   2,
+		},
+		Shutdown: ShutdownConfig{
+			Policy:          ShutdownGraceful,
+			GracefulSeconds: 15,
+		},
+	}
+}
+
+// parseConfig parses and validates the contents of a configuration file
+// over the defaults
+func parseConfig(configPath string, data []byte) (*Config, error) {
+	config := defaultConfig()
 
 	// Parse YAML
 	if err := yaml.Unmarshal(data, config); err != nil {
@@ -90,9 +298,30 @@ func LoadConfig(configPath string) (*Config, error) {
 // Validate checks if the configuration is valid
 func (c *Config) Validate() error {
 	// Validate services configuration
-	if len(c.Services.Namespaces) == 0 {
+	if len(c.Services.Namespaces) == 0 && !c.WatchesAllNamespaces() {
 		return fmt.Errorf("at least one namespace must be specified")
 	}
+	if _, err := c.GetNamespaceSelector(); err != nil {
+		return fmt.Errorf("invalid namespace_selector: %v", err)
+	}
+	if _, err := c.GetServiceSelector(); err != nil {
+		return fmt.Errorf("invalid service_selector: %v", err)
+	}
+
+	// Validate address types
+	if len(c.Services.AddressTypes) == 0 {
+		return fmt.Errorf("at least one address type must be specified")
+	}
+	for _, addressType := range c.Services.AddressTypes {
+		if !IsValidAddressType(addressType) {
+			return fmt.Errorf("invalid address type: %s (must be clusterip, externalip, loadbalancer, or loadbalancerip)", addressType)
+		}
+	}
+
+	// Validate advertise mode
+	if c.Services.AdvertiseMode != AdvertiseModeOptOut && c.Services.AdvertiseMode != AdvertiseModeOptIn {
+		return fmt.Errorf("invalid advertise mode: %s (must be opt-out or opt-in)", c.Services.AdvertiseMode)
+	}
 
 	// Validate loop interval
 	if c.LoopIntervalSeconds <= 0 {
@@ -124,6 +353,95 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("frr.socket_path cannot be empty")
 	}
 
+	// Validate FRR persistence mode
+	switch c.FRR.Persistence {
+	case PersistenceRuntime, PersistenceWriteMemory:
+	case PersistenceIncludeFile:
+		if c.FRR.IncludePath == "" {
+			return fmt.Errorf("frr.include_path cannot be empty in include-file mode")
+		}
+	default:
+		return fmt.Errorf("invalid frr persistence: %s (must be runtime, write-memory, or include-file)", c.FRR.Persistence)
+	}
+
+	// Validate BGP backend
+	switch c.BGP.Backend {
+	case BackendFRR:
+	case BackendGoBGP:
+	default:
+		return fmt.Errorf("invalid bgp backend: %s (must be frr or gobgp)", c.BGP.Backend)
+	}
+	if c.IsBGPManaged() && c.BGP.ASN <= 0 {
+		return fmt.Errorf("bgp.asn must be set when cosmolet manages the BGP configuration")
+	}
+	if c.BGP.RouterID != "" {
+		if ip := net.ParseIP(c.BGP.RouterID); ip == nil || ip.To4() == nil {
+			return fmt.Errorf("bgp.router_id must be an IPv4 address")
+		}
+	}
+	for _, peer := range c.BGP.Peers {
+		if err := peer.Validate(); err != nil {
+			return err
+		}
+	}
+
+	// Validate default communities
+	for _, community := range c.BGP.Communities {
+		if _, err := route.ParseCommunity(community); err != nil {
+			return fmt.Errorf("invalid bgp.communities: %v", err)
+		}
+	}
+	for _, community := range c.BGP.LargeCommunities {
+		if _, err := route.ParseLargeCommunity(community); err != nil {
+			return fmt.Errorf("invalid bgp.large_communities: %v", err)
+		}
+	}
+
+	// Validate path attributes
+	if err := c.BGP.Path.validate(); err != nil {
+		return fmt.Errorf("invalid bgp configuration: %v", err)
+	}
+	for name, node := range c.BGP.Nodes {
+		if err := node.validate(); err != nil {
+			return fmt.Errorf("invalid bgp configuration for node %s: %v", name, err)
+		}
+	}
+
+	// Validate interface name
+	if c.Interface.Name == "" || len(c.Interface.Name) > 15 {
+		return fmt.Errorf("interface.name must be between 1 and 15 characters")
+	}
+
+	// Validate leader election
+	if c.Election.Enabled {
+		if c.Election.LeaseName == "" {
+			return fmt.Errorf("election.lease_name cannot be empty")
+		}
+		if c.Election.RetryPeriodSeconds <= 0 {
+			return fmt.Errorf("election.retry_period_seconds must be positive")
+		}
+		if c.Election.RenewDeadlineSeconds <= c.Election.RetryPeriodSeconds {
+			return fmt.Errorf("election.renew_deadline_seconds must be greater than election.retry_period_seconds")
+		}
+		if c.Election.LeaseDurationSeconds <= c.Election.RenewDeadlineSeconds {
+			return fmt.Errorf("election.lease_duration_seconds must be greater than election.renew_deadline_seconds")
+		}
+	}
+
+	// Validate shutdown policy
+	switch c.Shutdown.Policy {
+	case ShutdownKeep, ShutdownWithdraw, ShutdownGraceful:
+	default:
+		return fmt.Errorf("shutdown.policy must be %q, %q or %q", ShutdownKeep, ShutdownWithdraw, ShutdownGraceful)
+	}
+	if c.Shutdown.GracefulSeconds < 0 {
+		return fmt.Errorf("shutdown.graceful_seconds cannot be negative")
+	}
+	// The embedded speaker's routes go away with its sessions
+	if c.Shutdown.Policy == ShutdownKeep && c.BGP.Backend == BackendGoBGP {
+		return fmt.Errorf("shutdown.policy %q is not supported by the %s backend", ShutdownKeep, BackendGoBGP)
+	}
+
 	return nil
 }
 
@@ -132,6 +450,182 @@ func (c *Config) GetNamespaces() []string {
 	return c.Services.Namespaces
 }
 
+// WatchesAllNamespaces returns true if services are watched cluster-wide,
+// either in every namespace or in those matching the namespace selector
+func (c *Config) WatchesAllNamespaces() bool {
+	return c.Services.AllNamespaces || c.Services.NamespaceSelector != ""
+}
+
+// GetNamespaceSelector returns the parsed namespace label selector. An empty
+// selector matches every namespace.
+func (c *Config) GetNamespaceSelector() (labels.Selector, error) {
+	if c.Services.AllNamespaces {
+		return labels.Everything(), nil
+	}
+	return labels.Parse(c.Services.NamespaceSelector)
+}
+
+// GetServiceSelector returns the parsed service label selector. An empty
+// selector matches every service.
+func (c *Config) GetServiceSelector() (labels.Selector, error) {
+	return labels.Parse(c.Services.ServiceSelector)
+}
+
+// IsBGPManaged returns true if cosmolet owns the base BGP configuration:
+// router-id and peers
+func (c *Config) IsBGPManaged() bool {
+	return c.BGP.Managed || c.BGP.Backend == BackendGoBGP
+}
+
+// GetRouterID returns the configured router-id, or nodeIP if it is an IPv4
+// address. It returns an error if neither is usable.
+func (c *Config) GetRouterID(nodeIP string) (string, error) {
+	if c.BGP.RouterID != "" {
+		return c.BGP.RouterID, nil
+	}
+	if ip := net.ParseIP(nodeIP); ip != nil && ip.To4() != nil {
+		return ip.String(), nil
+	}
+	return "", fmt.Errorf("bgp.router_id is not set and the node IP %q is not an IPv4 address", nodeIP)
+}
+
+// Validate checks a peer's address, AS and session options
+func (p PeerConfig) Validate() error {
+	if net.ParseIP(p.Address) == nil {
+		return fmt.Errorf("invalid bgp peer address: %s", p.Address)
+	}
+	if p.RemoteAS <= 0 {
+		return fmt.Errorf("bgp peer %s must have a positive remote_as", p.Address)
+	}
+	if p.EBGPMultihop < 0 || p.EBGPMultihop > 255 {
+		return fmt.Errorf("bgp peer %s ebgp_multihop must be between 0 and 255", p.Address)
+	}
+	if (p.KeepaliveSeconds == 0) != (p.HoldTimeSeconds == 0) {
+		return fmt.Errorf("bgp peer %s must set both keepalive_seconds and hold_time_seconds", p.Address)
+	}
+	if p.HoldTimeSeconds != 0 && (p.HoldTimeSeconds < 3 || p.KeepaliveSeconds >= p.HoldTimeSeconds) {
+		return fmt.Errorf("bgp peer %s hold_time_seconds must be at least 3 and greater than keepalive_seconds", p.Address)
+	}
+	return nil
+}
+
+// GetPathConfig returns the path attributes for a node: the node's
+// overrides merged over the global defaults
+func (c *Config) GetPathConfig(nodeName string) PathConfig {
+	path := c.BGP.Path
+	node, ok := c.BGP.Nodes[nodeName]
+	if !ok {
+		return path
+	}
+
+	if node.Path.LocalPref != nil {
+		path.LocalPref = node.Path.LocalPref
+	}
+	if node.Path.MED != nil {
+		path.MED = node.Path.MED
+	}
+	if node.Path.ASPathPrepend > 0 {
+		path.ASPathPrepend = node.Path.ASPathPrepend
+	}
+	return path
+}
+
+// ForNode returns a copy of the configuration with the BGP settings of the
+// named node merged over the global ones: first the node's entry in
+// bgp.nodes, then overrides, e.g. read from the Node object
+func (c *Config) ForNode(nodeName string, overrides NodeConfig) (*Config, error) {
+	merged := *c
+	if node, ok := c.BGP.Nodes[nodeName]; ok {
+		merged.BGP.applyNode(node)
+	}
+	merged.BGP.applyNode(overrides)
+
+	if err := merged.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration for node %s: %v", nodeName, err)
+	}
+	return &merged, nil
+}
+
+// RestartRequired returns the settings that differ in next but are only read
+// when cosmolet starts, so that a reload changing them can be rejected
+func (c *Config) RestartRequired(next *Config) []string {
+	var changed []string
+	check := func(name string, current, updated interface{}) {
+		if !reflect.DeepEqual(current, updated) {
+			changed = append(changed, name)
+		}
+	}
+	check("bgp.enabled", c.BGP.Enabled, next.BGP.Enabled)
+	check("bgp.backend", c.BGP.Backend, next.BGP.Backend)
+	check("bgp.managed", c.BGP.Managed, next.BGP.Managed)
+	check("bgp.asn", c.BGP.ASN, next.BGP.ASN)
+	check("bgp.router_id", c.BGP.RouterID, next.BGP.RouterID)
+	check("bgp.listen_port", c.BGP.ListenPort, next.BGP.ListenPort)
+	check("frr", c.FRR, next.FRR)
+	check("election", c.Election, next.Election)
+	check("interface", c.Interface, next.Interface)
+	check("custom_resources", c.CustomResources, next.CustomResources)
+	return changed
+}
+
+// applyNode overrides the ASN, router-id and peers with the node's values
+func (b *BGPConfig) applyNode(node NodeConfig) {
+	if node.ASN > 0 {
+		b.ASN = node.ASN
+	}
+	if node.RouterID != "" {
+		b.RouterID = node.RouterID
+	}
+	if len(node.Peers) > 0 {
+		b.Peers = node.Peers
+	}
+}
+
+// validate checks a node's overrides
+func (n NodeConfig) validate() error {
+	if n.ASN < 0 {
+		return fmt.Errorf("asn must be positive")
+	}
+	if n.RouterID != "" {
+		if ip := net.ParseIP(n.RouterID); ip == nil || ip.To4() == nil {
+			return fmt.Errorf("router_id must be an IPv4 address")
+		}
+	}
+	for _, peer := range n.Peers {
+		if err := peer.Validate(); err != nil {
+			return err
+		}
+	}
+	return n.Path.validate()
+}
+
+// validate checks the path attribute ranges
+func (p PathConfig) validate() error {
+	if p.ASPathPrepend < 0 || p.ASPathPrepend > route.MaxASPathPrepend {
+		return fmt.Errorf("as_path_prepend must be between 0 and %d", route.MaxASPathPrepend)
+	}
+	return nil
+}
+
+// GetAddressTypes returns the service address types to advertise
+func (c *Config) GetAddressTypes() []string {
+	return c.Services.AddressTypes
+}
+
+// GetAdvertiseMode returns the default advertisement mode for services
+func (c *Config) GetAdvertiseMode() string {
+	return c.Services.AdvertiseMode
+}
+
+// IsValidAddressType reports whether addressType is a known service address type
+func IsValidAddressType(addressType string) bool {
+	switch addressType {
+	case AddressTypeClusterIP, AddressTypeExternalIP, AddressTypeLoadBalancer, AddressTypeLoadBalancerIP:
+		return true
+	}
+	return false
+}
+
 // GetLoopInterval returns the loop interval duration
 func (c *Config) GetLoopInterval() int {
 	return c.LoopIntervalSeconds
@@ -147,6 +641,16 @@ func (c *Config) GetBGPASN() int {
 	return c.BGP.ASN
 }
 
+// GetBGPBackend returns the route advertisement backend
+func (c *Config) GetBGPBackend() string {
+	return c.BGP.Backend
+}
+
+// GetInterfaceName returns the interface service addresses are assigned to
+func (c *Config) GetInterfaceName() string {
+	return c.Interface.Name
+}
+
 // GetFRRSocketPath returns the FRR socket path
 func (c *Config) GetFRRSocketPath() string {
 	return c.FRR.SocketPath
@@ -156,3 +660,29 @@ func (c *Config) GetFRRSocketPath() string {
 func (c *Config) GetFRRConfigPath() string {
 	return c.FRR.ConfigPath
 }
+
+// IsCustomResourcesEnabled returns true if custom resources are watched
+func (c *Config) IsCustomResourcesEnabled() bool {
+	return c.CustomResources.Enabled
+}
+
+// GetFRRPersistence returns how service routes are persisted in FRR
+func (c *Config) GetFRRPersistence() string {
+	return c.FRR.Persistence
+}
+
+// GetShutdownPolicy returns what happens to advertised routes on shutdown
+func (c *Config) GetShutdownPolicy() string {
+	return c.Shutdown.Policy
+}
+
+// GetShutdownGracefulSeconds returns how long routes carry the
+// graceful-shutdown community before they are withdrawn
+func (c *Config) GetShutdownGracefulSeconds() int {
+	return c.Shutdown.GracefulSeconds
+}
+
+// IsLeaderElectionEnabled returns whether leader election is enabled
+func (c *Config) IsLeaderElectionEnabled() bool {
+	return c.Election.Enabled
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"time"
+
+	"cosmolet/pkg/metrics"
+)
+
+// InvalidError marks a configuration that is rejected as written, so that
+// retrying it without changes cannot succeed
+type InvalidError struct {
+	Err error
+}
+
+// Error returns the underlying error message
+func (e *InvalidError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *InvalidError) Unwrap() error {
+	return e.Err
+}
+
+// Watcher polls the configuration file and hands every changed, valid
+// configuration to a callback. Polling also catches the symlink swap the
+// kubelet uses to update mounted ConfigMaps.
+type Watcher struct {
+	path     string
+	interval time.Duration
+	data     []byte
+}
+
+// NewWatcher creates a watcher for the file at path, treating its current
+// contents as already applied
+func NewWatcher(path string, interval time.Duration) *Watcher {
+	data, _ := ioutil.ReadFile(path)
+	return &Watcher{path: path, interval: interval, data: data}
+}
+
+// Run polls the file until ctx is cancelled. A changed file is parsed and
+// validated and then passed to apply; if either fails, the error is logged
+// and counted and the running configuration stays in effect. A file that is
+// invalid, or that apply rejects with an InvalidError, is only tried again
+// once it changes; any other apply error is retried on the next poll.
+func (w *Watcher) Run(ctx context.Context, apply func(*Config) error) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			data, err := ioutil.ReadFile(w.path)
+			if err != nil || bytes.Equal(data, w.data) {
+				continue
+			}
+
+			log.Printf("Configuration file %s changed, reloading", w.path)
+			cfg, err := parseConfig(w.path, data)
+			if err != nil {
+				err = &InvalidError{Err: err}
+			} else {
+				err = apply(cfg)
+			}
+			if err != nil {
+				var invalid *InvalidError
+				if errors.As(err, &invalid) {
+					w.data = data
+				}
+				log.Printf("Configuration reload failed, keeping the previous configuration: %v", err)
+				metrics.ConfigReloadsTotal.WithLabelValues("error").Inc()
+				continue
+			}
+			w.data = data
+
+			log.Printf("Configuration reloaded from %s", w.path)
+			metrics.ConfigReloadsTotal.WithLabelValues("success").Inc()
+			metrics.ConfigLastReloadSuccess.SetToCurrentTime()
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"net"
+
+	"cosmolet/pkg/config"
+	"cosmolet/pkg/route"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// persistedServiceKey marks routes adopted from a previous run, whose
+// service and attributes are unknown
+const persistedServiceKey = "persisted"
+
+// desiredRoute describes a service address that should be advertised
+type desiredRoute struct {
+	serviceKey string
+	// nodeLocal routes follow a Local traffic policy and are advertised by
+	// every node with a usable local endpoint, regardless of leadership
+	nodeLocal bool
+	// attributes are the BGP path attributes set on the route
+	attributes route.Attributes
+	// policy is the ServiceAdvertisementPolicy applied to the service, if any
+	policy string
+}
+
+// serviceAddresses returns the unique, valid IPs of a service for the given
+// address types, in a stable order
+func serviceAddresses(service *v1.Service, addressTypes []string) []string {
+	var candidates []string
+
+	for _, addressType := range addressTypes {
+		switch addressType {
+		case config.AddressTypeClusterIP:
+			// ClusterIPs holds one address per IP family on dual-stack
+			// services; older API servers only populate ClusterIP
+			if len(service.Spec.ClusterIPs) > 0 {
+				candidates = append(candidates, service.Spec.ClusterIPs...)
+			} else {
+				candidates = append(candidates, service.Spec.ClusterIP)
+			}
+		case config.AddressTypeExternalIP:
+			candidates = append(candidates, service.Spec.ExternalIPs...)
+		case config.AddressTypeLoadBalancer:
+			if service.Spec.Type != v1.ServiceTypeLoadBalancer {
+				continue
+			}
+			for _, ingress := range service.Status.LoadBalancer.Ingress {
+				candidates = append(candidates, ingress.IP)
+			}
+		case config.AddressTypeLoadBalancerIP:
+			if service.Spec.Type != v1.ServiceTypeLoadBalancer {
+				continue
+			}
+			candidates = append(candidates, service.Spec.LoadBalancerIP)
+		}
+	}
+
+	var addresses []string
+	seen := make(map[string]bool)
+	for _, candidate := range candidates {
+		// Skips empty values as well as "None" for headless services
+		ip := net.ParseIP(candidate)
+		if ip == nil {
+			continue
+		}
+		address := ip.String()
+		if seen[address] {
+			continue
+		}
+		seen[address] = true
+		addresses = append(addresses, address)
+	}
+
+	return addresses
+}
+
+// requiresLocalEndpoint reports whether traffic to ip is only delivered to
+// endpoints on the receiving node: internalTrafficPolicy applies to cluster
+// IPs, externalTrafficPolicy to every other address
+func requiresLocalEndpoint(service *v1.Service, ip string) bool {
+	if isClusterIP(service, ip) {
+		return service.Spec.InternalTrafficPolicy != nil &&
+			*service.Spec.InternalTrafficPolicy == v1.ServiceInternalTrafficPolicyLocal
+	}
+	return service.Spec.ExternalTrafficPolicy == v1.ServiceExternalTrafficPolicyLocal
+}
+
+// isClusterIP reports whether ip is one of the service's cluster IPs
+func isClusterIP(service *v1.Service, ip string) bool {
+	clusterIPs := service.Spec.ClusterIPs
+	if len(clusterIPs) == 0 {
+		clusterIPs = []string{service.Spec.ClusterIP}
+	}
+	for _, clusterIP := range clusterIPs {
+		if parsed := net.ParseIP(clusterIP); parsed != nil && parsed.String() == ip {
+			return true
+		}
+	}
+	return false
+}
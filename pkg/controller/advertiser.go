@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"cosmolet/pkg/config"
+	"cosmolet/pkg/frr"
+	"cosmolet/pkg/gobgp"
+	"cosmolet/pkg/netif"
+	"cosmolet/pkg/route"
+)
+
+// RouteAdvertiser announces and withdraws host routes for service addresses.
+// Implementations must be idempotent: advertising an address twice or
+// withdrawing one that is not advertised is not an error.
+type RouteAdvertiser interface {
+	// Ping checks that the routing backend is reachable
+	Ping() error
+	// Configure applies the base BGP configuration (router-id and the given
+	// peers) when cosmolet manages it
+	Configure(peers []config.PeerConfig) error
+	// Sessions returns the BGP session state of every neighbor, by address,
+	// named as FRR names it: Idle, Connect, Active, OpenSent, OpenConfirm or
+	// Established
+	Sessions() (map[string]string, error)
+	// Advertised returns the service addresses currently announced from
+	// this node
+	Advertised() (map[string]bool, error)
+	// Apply announces the given routes, replacing the attributes of routes
+	// already announced, and withdraws the host routes for the given
+	// addresses in a single batch. It may reuse the state read by the
+	// preceding Advertised call, which callers make right before it.
+	Apply(advertise []route.Route, withdraw []string) error
+	// Persisted returns the addresses a previous run left behind on the
+	// service interface or in persisted configuration, which are reconciled
+	// against the cluster on startup
+	Persisted() ([]string, error)
+}
+
+// stoppingAdvertiser is implemented by route advertisers that shut down in
+// the background when the controller's context is cancelled
+type stoppingAdvertiser interface {
+	// Stopped is closed once the advertiser has shut down
+	Stopped() <-chan struct{}
+}
+
+// NewRouteAdvertiser creates the route advertiser for the configured backend
+// and prepares the interface service addresses are assigned to. In managed
+// mode the router-id defaults to the node IP from the NODE_IP environment
+// variable.
+func NewRouteAdvertiser(ctx context.Context, cfg *config.Config) (RouteAdvertiser, error) {
+	addresses := netif.NewManager(cfg.GetInterfaceName())
+	if err := addresses.Ensure(); err != nil {
+		return nil, err
+	}
+
+	var routerID string
+	if cfg.IsBGPManaged() {
+		var err error
+		if routerID, err = cfg.GetRouterID(os.Getenv("NODE_IP")); err != nil {
+			return nil, err
+		}
+	}
+
+	switch cfg.GetBGPBackend() {
+	case config.BackendFRR:
+		return frr.NewVtyshAdvertiser(cfg, addresses, routerID), nil
+	case config.BackendGoBGP:
+		return gobgp.NewSpeaker(ctx, cfg, addresses, routerID)
+	default:
+		return nil, fmt.Errorf("unknown BGP backend: %s", cfg.GetBGPBackend())
+	}
+}
@@ -0,0 +1,186 @@
+package controller
+
+import (
+	"log"
+	"strconv"
+	"strings"
+
+	"cosmolet/pkg/config"
+	"cosmolet/pkg/crd"
+	"cosmolet/pkg/route"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// Service annotations that override the cluster-wide advertisement policy
+const (
+	// AnnotationAdvertise opts a service in ("true") or out ("false") of
+	// advertisement, overriding services.advertise_mode
+	AnnotationAdvertise = "cosmolet.io/advertise"
+	// AnnotationAddressTypes is a comma separated list of address types that
+	// replaces services.address_types for the service
+	AnnotationAddressTypes = "cosmolet.io/address-types"
+	// AnnotationCommunities and AnnotationLargeCommunities are comma
+	// separated lists that replace bgp.communities and bgp.large_communities
+	// for the service; an empty value sets none
+	AnnotationCommunities      = "cosmolet.io/bgp-communities"
+	AnnotationLargeCommunities = "cosmolet.io/bgp-large-communities"
+	// AnnotationLocalPref, AnnotationMED and AnnotationASPathPrepend override
+	// the path attributes configured globally and for the node
+	AnnotationLocalPref     = "cosmolet.io/bgp-local-pref"
+	AnnotationMED           = "cosmolet.io/bgp-med"
+	AnnotationASPathPrepend = "cosmolet.io/bgp-as-path-prepend"
+)
+
+// advertisedAddresses returns the addresses of a service that should be
+// advertised according to the configuration, the policy applied to the
+// service (if any) and the service's annotations
+func (c *BGPServiceController) advertisedAddresses(service *v1.Service, policy *crd.ServiceAdvertisementPolicy) []string {
+	advertise := c.config.GetAdvertiseMode() != config.AdvertiseModeOptIn
+	addressTypes := c.config.GetAddressTypes()
+	if policy != nil {
+		if policy.Spec.Advertise != nil {
+			advertise = *policy.Spec.Advertise
+		}
+		if len(policy.Spec.AddressTypes) > 0 {
+			addressTypes = policy.Spec.AddressTypes
+		}
+	}
+
+	if !shouldAdvertise(service, advertise) {
+		return nil
+	}
+	return serviceAddresses(service, addressTypesFor(service, addressTypes))
+}
+
+// shouldAdvertise applies the cosmolet.io/advertise annotation, falling back
+// to defaultAdvertise when it is missing or invalid
+func shouldAdvertise(service *v1.Service, defaultAdvertise bool) bool {
+	value, ok := service.Annotations[AnnotationAdvertise]
+	if ok {
+		advertise, err := strconv.ParseBool(value)
+		if err == nil {
+			return advertise
+		}
+		log.Printf("Warning: ignoring invalid %s annotation %q on service %s/%s", AnnotationAdvertise, value, service.Namespace, service.Name)
+	}
+	return defaultAdvertise
+}
+
+// addressTypesFor applies the cosmolet.io/address-types annotation. Unknown
+// types are ignored; if none are left the defaults are used.
+func addressTypesFor(service *v1.Service, defaults []string) []string {
+	value, ok := service.Annotations[AnnotationAddressTypes]
+	if !ok {
+		return defaults
+	}
+
+	var addressTypes []string
+	for _, addressType := range strings.Split(value, ",") {
+		addressType = strings.ToLower(strings.TrimSpace(addressType))
+		if !config.IsValidAddressType(addressType) {
+			log.Printf("Warning: ignoring unknown address type %q in %s annotation on service %s/%s", addressType, AnnotationAddressTypes, service.Namespace, service.Name)
+			continue
+		}
+		addressTypes = append(addressTypes, addressType)
+	}
+
+	if len(addressTypes) == 0 {
+		return defaults
+	}
+	return addressTypes
+}
+
+// routeAttributes returns the BGP attributes for the routes of a service.
+// Annotations take precedence over the policy applied to the service, then
+// the node's overrides and finally the global defaults.
+func (c *BGPServiceController) routeAttributes(service *v1.Service, policy *crd.ServiceAdvertisementPolicy) route.Attributes {
+	communities := c.config.BGP.Communities
+	largeCommunities := c.config.BGP.LargeCommunities
+	path := c.config.GetPathConfig(c.nodeName)
+	if policy != nil {
+		if policy.Spec.Communities != nil {
+			communities = policy.Spec.Communities
+		}
+		if policy.Spec.LargeCommunities != nil {
+			largeCommunities = policy.Spec.LargeCommunities
+		}
+		if policy.Spec.LocalPref != nil {
+			path.LocalPref = policy.Spec.LocalPref
+		}
+		if policy.Spec.MED != nil {
+			path.MED = policy.Spec.MED
+		}
+		if policy.Spec.ASPathPrepend != nil {
+			path.ASPathPrepend = *policy.Spec.ASPathPrepend
+		}
+	}
+
+	attributes := route.NewAttributes(
+		communitiesFor(service, AnnotationCommunities, communities, route.ParseCommunity),
+		communitiesFor(service, AnnotationLargeCommunities, largeCommunities, route.ParseLargeCommunity),
+	)
+
+	attributes.LocalPref = uint32Annotation(service, AnnotationLocalPref, path.LocalPref)
+	attributes.MED = uint32Annotation(service, AnnotationMED, path.MED)
+	attributes.ASPathPrepend = prependAnnotation(service, path.ASPathPrepend)
+	return attributes
+}
+
+// uint32Annotation parses a 32-bit unsigned annotation, falling back to
+// defaultValue when it is missing or invalid
+func uint32Annotation(service *v1.Service, annotation string, defaultValue *uint32) *uint32 {
+	value, ok := service.Annotations[annotation]
+	if !ok {
+		return defaultValue
+	}
+
+	parsed, err := strconv.ParseUint(strings.TrimSpace(value), 10, 32)
+	if err != nil {
+		log.Printf("Warning: ignoring invalid %s annotation %q on service %s/%s", annotation, value, service.Namespace, service.Name)
+		return defaultValue
+	}
+	result := uint32(parsed)
+	return &result
+}
+
+// prependAnnotation parses the AS-path prepend count, falling back to
+// defaultValue when it is missing or out of range
+func prependAnnotation(service *v1.Service, defaultValue int) int {
+	value, ok := service.Annotations[AnnotationASPathPrepend]
+	if !ok {
+		return defaultValue
+	}
+
+	count, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || count < 0 || count > route.MaxASPathPrepend {
+		log.Printf("Warning: ignoring invalid %s annotation %q on service %s/%s", AnnotationASPathPrepend, value, service.Namespace, service.Name)
+		return defaultValue
+	}
+	return count
+}
+
+// communitiesFor returns the normalized communities from the annotation if
+// present, or from defaults. Invalid entries are ignored.
+func communitiesFor(service *v1.Service, annotation string, defaults []string, parse func(string) (string, error)) []string {
+	values := defaults
+	if value, ok := service.Annotations[annotation]; ok {
+		values = nil
+		for _, community := range strings.Split(value, ",") {
+			if strings.TrimSpace(community) != "" {
+				values = append(values, community)
+			}
+		}
+	}
+
+	var communities []string
+	for _, value := range values {
+		community, err := parse(value)
+		if err != nil {
+			log.Printf("Warning: ignoring %v on service %s/%s", err, service.Namespace, service.Name)
+			continue
+		}
+		communities = append(communities, community)
+	}
+	return communities
+}
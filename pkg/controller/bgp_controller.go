@@ -4,16 +4,24 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"os/exec"
-	"strings"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
-	"net"
+
 	"cosmolet/pkg/config"
 	"cosmolet/pkg/health"
+	"cosmolet/pkg/metrics"
+	"cosmolet/pkg/route"
 
 	v1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/workqueue"
 )
 
 // BGPServiceController manages BGP advertisements for Kubernetes services
@@ -22,10 +30,64 @@ type BGPServiceController struct {
 	config        *config.Config
 	ctx           context.Context
 	healthChecker *health.Checker
+	advertiser    RouteAdvertiser
+
+	// mu guards the configuration and the informers, which Reload replaces
+	// while the reconcile loop holds it
+	mu sync.Mutex
+
+	informers map[string]*namespaceInformers
+	queue     workqueue.TypedRateLimitingInterface[string]
+	// reconcileDelay is how long events are collected before a reconcile
+	reconcileDelay time.Duration
+
+	namespaceSelector labels.Selector
+	serviceSelector   labels.Selector
+
+	// nodeName is the node this instance runs on, used to find node-local
+	// endpoints for services with a Local traffic policy
+	nodeName string
+
+	// leading is true while this instance may advertise routes; it is always
+	// true when leader election is disabled
+	leading atomic.Bool
+
+	// nodeLabels are this node's labels, matched against BGPPeer node
+	// selectors
+	nodeLabels labels.Set
+
+	// dynamicClient and crdInformers serve the custom resources; both are
+	// nil when custom resources are disabled
+	dynamicClient dynamic.Interface
+	crdInformers  *customResourceInformers
+
+	// policies holds the ServiceAdvertisementPolicies by namespace, loaded
+	// at the start of every reconcile
+	policies map[string][]servicePolicy
+
+	// appliedPeers is the peer set last applied to the backend; configured
+	// is false until the first Configure succeeds
+	appliedPeers []config.PeerConfig
+	configured   bool
+	// backendLost is set when the routing backend stopped answering, after
+	// which the peers are applied again in case it restarted without them
+	backendLost atomic.Bool
+
+	// stopped is set by Shutdown, after which reconciles leave routes alone
+	stopped bool
+	// synced is set once the informer caches first synced; reloads that
+	// change informers wait for it
+	synced bool
+
+	// advertised maps each service address announced by this controller to the
+	// route last applied for it, so that stale routes can be withdrawn and
+	// changed attributes re-applied
+	advertised map[string]desiredRoute
 }
 
-// NewBGPServiceController creates a new BGP service controller
-func NewBGPServiceController(cfg *config.Config, ctx context.Context) (*BGPServiceController, error) {
+// NewBGPServiceController creates a new BGP service controller that reports
+// its state through healthChecker
+func NewBGPServiceController(cfg *config.Config, ctx context.Context, healthChecker *health.Checker) (*BGPServiceController, error) {
 	kubeConfig, err := GetKubeConfig()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get Kubernetes config: %w", err)
@@ -36,265 +98,395 @@ func NewBGPServiceController(cfg *config.Config, ctx context.Context) (*BGPServi
 		return nil, fmt.Errorf("failed to create Kubernetes client: %v", err)
 	}
 
-	return &BGPServiceController{
-		client:        clientset,
+	// Racks may differ in ASN and peers, so merge this node's settings first
+	node, err := getNode(ctx, clientset, os.Getenv("NODE_NAME"))
+	if err != nil {
+		return nil, err
+	}
+	cfg, err = configForNode(cfg, node)
+	if err != nil {
+		return nil, err
+	}
+
+	advertiser, err := NewRouteAdvertiser(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s route advertiser: %v", cfg.GetBGPBackend(), err)
+	}
+
+	c := NewBGPServiceControllerWithClient(cfg, ctx, clientset, advertiser, healthChecker)
+	if node != nil {
+		c.nodeLabels = labels.Set(node.Labels)
+	}
+	if cfg.IsCustomResourcesEnabled() {
+		if c.dynamicClient, err = dynamic.NewForConfig(kubeConfig); err != nil {
+			return nil, fmt.Errorf("failed to create dynamic Kubernetes client: %v", err)
+		}
+	}
+	return c, nil
+}
+
+// NewBGPServiceControllerWithClient creates a controller around an existing
+// Kubernetes client and route advertiser, e.g. the client-go fake clientset
+// and a FakeAdvertiser
+func NewBGPServiceControllerWithClient(cfg *config.Config, ctx context.Context, client kubernetes.Interface, advertiser RouteAdvertiser, healthChecker *health.Checker) *BGPServiceController {
+	c := &BGPServiceController{
+		client:        client,
 		config:        cfg,
 		ctx:           ctx,
-		healthChecker: health.NewChecker(),
-	}, nil
+		healthChecker: healthChecker,
+		advertiser:    advertiser,
+		nodeName:      os.Getenv("NODE_NAME"),
+		queue: workqueue.NewTypedRateLimitingQueueWithConfig(
+			workqueue.DefaultTypedControllerRateLimiter[string](),
+			workqueue.TypedRateLimitingQueueConfig[string]{Name: "cosmolet"},
+		),
+		reconcileDelay: defaultReconcileDelay,
+		advertised:     make(map[string]desiredRoute),
+	}
+	c.leading.Store(!cfg.IsLeaderElectionEnabled())
+	return c
 }
 
-// Start begins the main control loop
+// Start runs the informers and the reconcile worker until the context is
+// cancelled, and returns once the leader election released its Lease and the
+// routing backend has stopped
 func (c *BGPServiceController) Start() error {
 	log.Println("Starting BGP Service Controller...")
 
-	if err := c.testKubernetesAPI(); err != nil {
-		c.healthChecker.CheckKubernetesAPI(false, err.Error())
+	if err := c.checkKubernetesAPI(); err != nil {
 		return fmt.Errorf("kubernetes API connectivity test failed: %v", err)
 	}
-	c.healthChecker.CheckKubernetesAPI(true, "Connected")
 
-	if err := c.testFRRConnectivity(); err != nil {
-		c.healthChecker.CheckFRRStatus(false, err.Error())
+	if err := c.checkFRRConnectivity(); err != nil {
 		log.Printf("Warning: FRR connectivity test failed: %v", err)
-	} else {
-		c.healthChecker.CheckFRRStatus(true, "Connected")
 	}
 
-	for {
-		select {
-		case <-c.ctx.Done():
-			log.Println("Received shutdown signal, stopping controller")
-			return nil
-		default:
-			c.runControlLoop()
-		}
+	if err := c.runInformers(); err != nil {
+		return err
+	}
+	c.restorePersistedRoutes()
+
+	go func() {
+		<-c.ctx.Done()
+		c.queue.ShutDown()
+	}()
+	if c.nodeName == "" {
+		log.Printf("Warning: NODE_NAME is not set, services with a Local traffic policy will not be advertised")
+	}
+
+	go c.runResync()
+	// Election settings are fixed at startup; read them before reloads can
+	// replace the configuration
+	c.mu.Lock()
+	election := c.config.Election
+	c.mu.Unlock()
+	var background sync.WaitGroup
+	if election.Enabled {
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			c.runLeaderElection(election)
+		}()
 	}
+
+	// Reconcile once immediately so that startup does not wait for an event
+	c.enqueue()
+	c.runWorker()
+
+	log.Println("Received shutdown signal, stopping controller")
+	// Wait for the Lease to be released and the backend to shut down
+	background.Wait()
+	if advertiser, ok := c.advertiser.(stoppingAdvertiser); ok {
+		<-advertiser.Stopped()
+	}
+	return nil
 }
 
-// runControlLoop executes one iteration of the control loop
-func (c *BGPServiceController) runControlLoop() {
+// runControlLoop executes one full reconcile of the desired advertisements
+func (c *BGPServiceController) runControlLoop() error {
 	start := time.Now()
 	log.Println("=== Starting new loop iteration ===")
 
 	c.healthChecker.UpdateLastLoop()
 
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stopped {
+		return nil
+	}
+
+	// Step 0: Load policies and apply the peers for this node
+	if err := c.loadPolicies(); err != nil {
+		return err
+	}
+	c.configurePeers()
+
 	// Step 1: Fetch all running services in configured namespaces
 	services, err := c.fetchServicesFromNamespaces()
 	if err != nil {
-		log.Printf("Error fetching services: %v", err)
 		c.healthChecker.CheckServiceDiscovery(0, time.Since(start))
-		c.sleep()
-		return
+		return fmt.Errorf("error fetching services: %v", err)
 	}
 
 	log.Printf("Found %d services to process", len(services))
 	c.healthChecker.CheckServiceDiscovery(len(services), time.Since(start))
 
-	// Step 2: Process each service
+	// Step 2: Process each service and collect the desired set of addresses
+	desired := make(map[string]desiredRoute)
 	for _, service := range services {
 		select {
 		case <-c.ctx.Done():
-			return
+			return nil
 		default:
-			c.processService(service)
+			c.processService(service, desired)
 		}
 	}
 
+	// Non-leaders keep evaluating health but leave advertising to the leader.
+	// Node-local routes depend on this node's endpoints, so every node keeps
+	// advertising its own.
+	if !c.isLeader() {
+		for ip, r := range desired {
+			if !r.nodeLocal {
+				delete(desired, ip)
+			}
+		}
+		log.Printf("Not the leader — advertising %d node-local addresses only", len(desired))
+	}
+
+	// Step 4-7: Diff against the routing backend and apply all changes at once
+	if err := c.applyRoutes(desired); err != nil {
+		return err
+	}
+	metrics.AdvertisedPrefixes.Set(float64(len(c.advertised)))
+
+	// Step 8: Report session state and applied prefixes on custom resources
+	c.updatePeerStatuses()
+	c.updatePolicyStatuses()
+
 	duration := time.Since(start)
-	log.Printf("Loop finished in %v. Sleeping for %d seconds...", duration, c.config.GetLoopInterval())
-	c.sleep()
+	log.Printf("Loop finished in %v", duration)
+	return nil
 }
 
-// fetchServicesFromNamespaces fetches all services from configured namespaces
+// fetchServicesFromNamespaces fetches all services matching the service
+// selector with at least one advertisable address from the informer caches
+// of the watched namespaces
 func (c *BGPServiceController) fetchServicesFromNamespaces() ([]v1.Service, error) {
 	var allServices []v1.Service
 
-	for _, namespace := range c.config.GetNamespaces() {
+	namespaces, err := c.watchedNamespaces()
+	if err != nil {
+		return nil, err
+	}
+
+	// Namespaces can leave the selection, so drop their stale counts
+	metrics.ServicesDiscovered.Reset()
+	for _, namespace := range namespaces {
 		log.Printf("Fetching services from namespace: %s", namespace)
 
-		services, err := c.client.CoreV1().Services(namespace).List(c.ctx, metav1.ListOptions{})
+		services, err := c.informersFor(namespace).services.Services(namespace).List(c.serviceSelector)
 		if err != nil {
 			return nil, fmt.Errorf("failed to list services in namespace %s: %v", namespace, err)
 		}
 
-		for _, service := range services.Items {
-			if service.Spec.ClusterIP != "" && service.Spec.ClusterIP != "None" {
-				allServices = append(allServices, service)
+		count := 0
+		for _, service := range services {
+			if len(c.advertisedAddresses(service, c.policyFor(service))) > 0 {
+				allServices = append(allServices, *service)
+				count++
 			}
 		}
+		metrics.ServicesDiscovered.WithLabelValues(namespace).Set(float64(count))
 	}
 
 	return allServices, nil
 }
 
-// processService handles health and BGP advertisement for one service
-func (c *BGPServiceController) processService(service v1.Service) {
+// processService runs the health check for one service and records the
+// addresses of healthy services in desired; anything advertised but missing
+// from desired at the end of the loop is withdrawn.
+func (c *BGPServiceController) processService(service v1.Service, desired map[string]desiredRoute) {
 	serviceKey := fmt.Sprintf("%s/%s", service.Namespace, service.Name)
-	clusterIP := service.Spec.ClusterIP
+	policy := c.policyFor(&service)
+	addresses := c.advertisedAddresses(&service, policy)
+	attributes := c.routeAttributes(&service, policy)
 
-	log.Printf("Processing service: %s (ClusterIP: %s)", serviceKey, clusterIP)
+	log.Printf("Processing service: %s (addresses: %v)", serviceKey, addresses)
 
-	isHealthy, err := c.performHealthCheck(service)
+	health, err := c.performHealthCheck(service)
 	if err != nil {
 		log.Printf("Error performing health check for service %s: %v", serviceKey, err)
-		return
-	}
-	// Step 3: Decision - Service ClusterIP is healthy?
-	if !isHealthy {
-		log.Printf("Service %s marked unhealthy — skipping", serviceKey)
+		// Keep the current state on transient errors rather than flapping the route
+		for _, ip := range addresses {
+			if current, ok := c.advertised[ip]; ok {
+				desired[ip] = current
+			}
+		}
 		return
 	}
 
-	log.Printf("Service %s is healthy", serviceKey)
+	for _, ip := range addresses {
+		// Addresses under a Local traffic policy only count endpoints on this node
+		nodeLocal := requiresLocalEndpoint(&service, ip)
+		isHealthy := health.cluster
+		if nodeLocal {
+			isHealthy = health.local
+		}
 
-	// Step 4: Check if service ClusterIP is already advertised by FRR via BGP
-	isAdvertised, err := c.isServiceAdvertisedByFRR(clusterIP)
-	if err != nil {
-		log.Printf("Error checking BGP advertisement status for service %s: %v", serviceKey, err)
-		return
-	}
-	// Step 5: Decision - Service ClusterIP is already advertised?
-	if isAdvertised {
-		log.Printf("Service %s already advertised — nothing to do", serviceKey)
-		return
-	}
+		// Step 3: Decision - Service is healthy?
+		if !isHealthy {
+			log.Printf("Service %s (%s) marked unhealthy (node-local: %t) — not advertising", serviceKey, ip, nodeLocal)
+			continue
+		}
 
-	// Step 6: Advertise the Service ClusterIP using FRR
-	log.Printf("Advertising service %s (ClusterIP: %s) via BGP", serviceKey, clusterIP)
-	if err := c.advertiseServiceViaBGP(clusterIP); err != nil {
-		log.Printf("Error advertising service %s via BGP: %v", serviceKey, err)
-		return
+		log.Printf("Service %s (%s) is healthy (node-local: %t)", serviceKey, ip, nodeLocal)
+		desired[ip] = desiredRoute{serviceKey: serviceKey, nodeLocal: nodeLocal, attributes: attributes, policy: policyKey(policy)}
 	}
-	log.Printf("Successfully advertised service %s", serviceKey)
 }
 
-// performHealthCheck checks if service has at least one ready endpoint
-func (c *BGPServiceController) performHealthCheck(service v1.Service) (bool, error) {
-	serviceKey := fmt.Sprintf("%s/%s", service.Namespace, service.Name)
-
-	endpoints, err := c.client.CoreV1().Endpoints(service.Namespace).Get(c.ctx, service.Name, metav1.GetOptions{})
+// restorePersistedRoutes adopts the routes a previous run persisted, so that
+// the first reconcile withdraws those no longer backed by a healthy service
+func (c *BGPServiceController) restorePersistedRoutes() {
+	persisted, err := c.advertiser.Persisted()
 	if err != nil {
-		return false, fmt.Errorf("failed to get endpoints for service %s: %v", serviceKey, err)
+		log.Printf("Warning: failed to load persisted routes: %v", err)
+		return
 	}
 
-	readyEndpoints := 0
-	for _, subset := range endpoints.Subsets {
-		readyEndpoints += len(subset.Addresses)
+	for _, ip := range persisted {
+		c.advertised[ip] = desiredRoute{serviceKey: persistedServiceKey}
 	}
-
-	isHealthy := readyEndpoints > 0
-	log.Printf("Health check for service %s: %d ready endpoints, healthy: %t", serviceKey, readyEndpoints, isHealthy)
-
-	return isHealthy, nil
-}
-
-// isServiceAdvertisedByFRR checks if the ClusterIP is locally assigned and advertised via BGP
-func (c *BGPServiceController) isServiceAdvertisedByFRR(clusterIP string) (bool, error) {
-
-	iface, err := net.InterfaceByName("lo")
-	if err != nil {
-		return false, fmt.Errorf("failed to get loopback interface: %v", err)
+	if len(persisted) > 0 {
+		log.Printf("Adopted %d persisted routes for reconciliation", len(persisted))
 	}
+}
 
-	addrs, err := iface.Addrs()
+// applyRoutes diffs the desired addresses against what the routing backend
+// announces and pushes every advertisement and withdrawal in one batch.
+// Only addresses this controller advertised are ever withdrawn, so routes
+// configured on the node by other means are left alone.
+func (c *BGPServiceController) applyRoutes(desired map[string]desiredRoute) error {
+	// Step 4: Fetch the addresses the routing backend currently announces
+	actual, err := c.advertiser.Advertised()
 	if err != nil {
-		return false, fmt.Errorf("failed to get addresses on loopback: %v", err)
+		return fmt.Errorf("failed to list advertised routes: %v", err)
 	}
 
-	found := false
-	for _, addr := range addrs {
-		ip, _, err := net.ParseCIDR(addr.String())
-		if err != nil {
+	// Step 5: Decision - which addresses are missing, changed or stale?
+	var advertise []route.Route
+	var withdraw []string
+	for ip, want := range desired {
+		// Persisted routes carry unknown attributes, so they are re-applied
+		// once; so are routes that survived a restart untracked
+		current, ok := c.advertised[ip]
+		if ok && actual[ip] && current.serviceKey != persistedServiceKey && current.attributes.Equal(want.attributes) {
+			c.advertised[ip] = want
 			continue
 		}
-		if ip.String() == clusterIP {
-			found = true
-			break
+		if actual[ip] {
+			log.Printf("Updating service %s (%s) with %s", want.serviceKey, ip, want.attributes)
+		} else {
+			log.Printf("Advertising service %s (%s) via BGP", want.serviceKey, ip)
 		}
+		advertise = append(advertise, route.Route{IP: ip, Attributes: want.attributes})
 	}
-
-	if !found {
-		log.Printf("ClusterIP %s is NOT on loopback interface", clusterIP)
-		return false, nil
+	for ip, current := range c.advertised {
+		if _, ok := desired[ip]; !ok {
+			log.Printf("Withdrawing service %s (%s): no longer healthy or present", current.serviceKey, ip)
+			withdraw = append(withdraw, ip)
+		}
 	}
 
-	log.Printf("ClusterIP %s is on loopback interface", clusterIP)
-
-	// Step 2: Check if BGP is advertising this IP and sourced locally
-	cmd := exec.Command("vtysh", "-c", "show ip bgp " + clusterIP)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return false, fmt.Errorf("failed to check BGP advertisement for %s: %v\nOutput: %s", clusterIP, err, output)
+	if len(advertise) == 0 && len(withdraw) == 0 {
+		log.Printf("All %d desired addresses already advertised — nothing to do", len(desired))
+		return nil
 	}
+	sort.Slice(advertise, func(i, j int) bool { return advertise[i].IP < advertise[j].IP })
+	sort.Strings(withdraw)
+
+	// Step 6-7: Apply all advertisements and withdrawals in a single batch
+	if err := c.advertiser.Apply(advertise, withdraw); err != nil {
+		metrics.AdvertisementsTotal.WithLabelValues("error").Add(float64(len(advertise)))
+		metrics.WithdrawalsTotal.WithLabelValues("error").Add(float64(len(withdraw)))
+		return fmt.Errorf("failed to apply %d advertisements and %d withdrawals: %v", len(advertise), len(withdraw), err)
+	}
+	metrics.AdvertisementsTotal.WithLabelValues("success").Add(float64(len(advertise)))
+	metrics.WithdrawalsTotal.WithLabelValues("success").Add(float64(len(withdraw)))
 
-	outStr := string(output)
-	isLocal := strings.Contains(outStr, "sourced") && strings.Contains(outStr, "valid")
+	for _, r := range advertise {
+		c.advertised[r.IP] = desired[r.IP]
+	}
+	for _, ip := range withdraw {
+		delete(c.advertised, ip)
+	}
 
-	log.Printf("BGP advertisement check for %s: sourced locally = %v", clusterIP, isLocal)
-	return isLocal, nil
+	log.Printf("Successfully advertised %d and withdrew %d addresses", len(advertise), len(withdraw))
+	return nil
 }
 
-// advertiseServiceViaBGP adds loopback route and configures FRR
-func (c *BGPServiceController) advertiseServiceViaBGP(clusterIP string) error {
-	if !c.config.IsBGPEnabled() {
-		log.Printf("BGP is disabled in configuration, skipping advertisement")
-		return nil
-	}
+// performHealthCheck checks if service has at least one ready endpoint in its
+// EndpointSlices, optionally counting serving endpoints that are terminating.
+// Health is evaluated both cluster-wide and for endpoints on this node.
+func (c *BGPServiceController) performHealthCheck(service v1.Service) (serviceHealth, error) {
+	serviceKey := fmt.Sprintf("%s/%s", service.Namespace, service.Name)
 
-	route := fmt.Sprintf("%s/32", clusterIP)
-	asn := c.config.GetBGPASN()
-	log.Printf("Advertising route %s via BGP ASN %d", route, asn)
+	selector := labels.SelectorFromSet(labels.Set{discoveryv1.LabelServiceName: service.Name})
+	slices, err := c.informersFor(service.Namespace).endpointSlices.EndpointSlices(service.Namespace).List(selector)
+	if err != nil {
+		return serviceHealth{}, fmt.Errorf("failed to list endpointslices for service %s: %v", serviceKey, err)
+	}
 
-	assignCmd := exec.Command("ip", "addr", "add", route, "dev", "lo")
-	if output, err := assignCmd.CombinedOutput(); err != nil {
-		log.Printf("Warning: failed to assign IP to loopback: %v\nOutput: %s", err, output)
+	clusterCounts := countEndpoints(slices, "")
+	health := serviceHealth{cluster: c.isHealthy(clusterCounts)}
+	if c.nodeName != "" {
+		health.local = c.isHealthy(countEndpoints(slices, c.nodeName))
 	}
 
-	cmd := exec.Command(
-		"vtysh",
-		"-c", "configure terminal",
-		"-c", fmt.Sprintf("router bgp %d", asn),
-		"-c", "address-family ipv4 unicast",
-		"-c", fmt.Sprintf("network %s", route),
-		"-c", "exit-address-family",
-		"-c", "exit",
-	)
+	log.Printf("Health check for service %s: %d ready, %d serving-terminating endpoints, healthy: %t, healthy on node: %t",
+		serviceKey, clusterCounts.ready, clusterCounts.servingTerminating, health.cluster, health.local)
 
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("failed to advertise route via BGP: %v\nOutput: %s", err, output)
+	return health, nil
+}
+
+// isHealthy decides whether the counted endpoints can take traffic. While a
+// service is rolling or scaling down it may only have terminating endpoints
+// left; keep the route as long as they still serve traffic.
+func (c *BGPServiceController) isHealthy(counts endpointCounts) bool {
+	if counts.ready > 0 {
+		return true
 	}
-	log.Printf("vtysh route advertisement successful: %s", output)
+	return c.config.Services.IncludeTerminating && counts.servingTerminating > 0
+}
 
-	writeCmd := exec.Command("vtysh", "-c", "write memory")
-	if output, err := writeCmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("failed to persist config to /etc/frr/frr.conf: %v\nOutput: %s", err, output)
+// checkKubernetesAPI tests Kubernetes API access and reports the result to
+// the health checker
+func (c *BGPServiceController) checkKubernetesAPI() error {
+	if err := c.testKubernetesAPI(); err != nil {
+		c.healthChecker.CheckKubernetesAPI(false, err.Error())
+		return err
 	}
+	c.healthChecker.CheckKubernetesAPI(true, "Connected")
+	return nil
+}
 
-	log.Printf("Successfully advertised %s via BGP and saved config to /etc/frr/frr.conf", route)
+// checkFRRConnectivity tests routing backend availability and reports the
+// result to the health checker
+func (c *BGPServiceController) checkFRRConnectivity() error {
+	if err := c.advertiser.Ping(); err != nil {
+		c.backendLost.Store(true)
+		c.healthChecker.CheckFRRStatus(false, err.Error())
+		return err
+	}
+	c.healthChecker.CheckFRRStatus(true, "Connected")
 	return nil
 }
 
 // testKubernetesAPI tests Kubernetes API access
 func (c *BGPServiceController) testKubernetesAPI() error {
 	_, err := c.client.CoreV1().Namespaces().List(c.ctx, metav1.ListOptions{Limit: 1})
-	return err
-}
-
-// testFRRConnectivity tests FRR CLI availability
-func (c *BGPServiceController) testFRRConnectivity() error {
-	cmd := exec.Command("vtysh", "-c", "show version")
-	_, err := cmd.Output()
-	return err
-}
-
-// sleep for configured loop interval
-func (c *BGPServiceController) sleep() {
-	select {
-	case <-c.ctx.Done():
-		return
-	case <-time.After(time.Duration(c.config.GetLoopInterval()) * time.Second):
-		return
+	if err != nil {
+		metrics.KubernetesAPIErrorsTotal.WithLabelValues("list_namespaces").Inc()
 	}
+	return err
 }
@@ -0,0 +1,403 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cosmolet/pkg/config"
+	"cosmolet/pkg/health"
+	"cosmolet/pkg/route"
+
+	v1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+const testNode = "node-1"
+
+// countingAdvertiser counts reconciles, each of which lists the advertised
+// routes exactly once
+type countingAdvertiser struct {
+	*FakeAdvertiser
+	reconciles atomic.Int32
+}
+
+func (a *countingAdvertiser) Advertised() (map[string]bool, error) {
+	a.reconciles.Add(1)
+	return a.FakeAdvertiser.Advertised()
+}
+
+// testConfig loads a configuration from YAML over the defaults
+func testConfig(t *testing.T, content string) *config.Config {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+// startController runs a controller that debounces events for delay against
+// a fake clientset until the test ends
+func startController(t *testing.T, cfg *config.Config, advertiser RouteAdvertiser, delay time.Duration) (*BGPServiceController, *fake.Clientset) {
+	t.Helper()
+	t.Setenv("NODE_NAME", testNode)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	client := fake.NewSimpleClientset()
+	c := NewBGPServiceControllerWithClient(cfg, ctx, client, advertiser, health.NewChecker())
+	c.reconcileDelay = delay
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := c.Start(); err != nil {
+			t.Errorf("Start() failed: %v", err)
+		}
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return c, client
+}
+
+func testService(name, clusterIP string) *v1.Service {
+	return &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: v1.ServiceSpec{
+			Type:       v1.ServiceTypeClusterIP,
+			ClusterIP:  clusterIP,
+			ClusterIPs: []string{clusterIP},
+		},
+	}
+}
+
+func testEndpointSlice(service string, ready bool) *discoveryv1.EndpointSlice {
+	node := testNode
+	return &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      service + "-abcde",
+			Namespace: "default",
+			Labels:    map[string]string{discoveryv1.LabelServiceName: service},
+		},
+		AddressType: discoveryv1.AddressTypeIPv4,
+		Endpoints: []discoveryv1.Endpoint{{
+			Addresses:  []string{"10.244.1.5"},
+			Conditions: discoveryv1.EndpointConditions{Ready: &ready},
+			NodeName:   &node,
+		}},
+	}
+}
+
+// waitFor polls until done returns true or fails the test after 5 seconds
+func waitFor(t *testing.T, what string, done func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !done() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// waitForRoutes waits until the advertiser announces exactly want
+func waitForRoutes(t *testing.T, advertiser *FakeAdvertiser, want []string) {
+	t.Helper()
+	waitFor(t, fmt.Sprintf("routes %v", want), func() bool {
+		got := advertiser.Routes()
+		return reflect.DeepEqual(got, want) || (len(got) == 0 && len(want) == 0)
+	})
+}
+
+func TestServiceLifecycle(t *testing.T) {
+	advertiser := NewFakeAdvertiser()
+	_, client := startController(t, testConfig(t, "services:\n  namespaces: [default]\n"), advertiser, 50*time.Millisecond)
+	ctx := context.Background()
+
+	// Add: a service with a ready endpoint is advertised
+	if _, err := client.CoreV1().Services("default").Create(ctx, testService("web", "10.96.0.10"), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.DiscoveryV1().EndpointSlices("default").Create(ctx, testEndpointSlice("web", true), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitForRoutes(t, advertiser, []string{"10.96.0.10"})
+
+	// Health change: the last endpoint turning unready withdraws the route
+	if _, err := client.DiscoveryV1().EndpointSlices("default").Update(ctx, testEndpointSlice("web", false), metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitForRoutes(t, advertiser, nil)
+
+	if _, err := client.DiscoveryV1().EndpointSlices("default").Update(ctx, testEndpointSlice("web", true), metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitForRoutes(t, advertiser, []string{"10.96.0.10"})
+
+	// Delete: removing the service withdraws the route
+	if err := client.CoreV1().Services("default").Delete(ctx, "web", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitForRoutes(t, advertiser, nil)
+}
+
+func TestServiceWithoutEndpointsIsNotAdvertised(t *testing.T) {
+	advertiser := NewFakeAdvertiser()
+	_, client := startController(t, testConfig(t, "services:\n  namespaces: [default]\n"), advertiser, 50*time.Millisecond)
+	ctx := context.Background()
+
+	if _, err := client.CoreV1().Services("default").Create(ctx, testService("idle", "10.96.0.11"), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CoreV1().Services("default").Create(ctx, testService("web", "10.96.0.10"), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.DiscoveryV1().EndpointSlices("default").Create(ctx, testEndpointSlice("web", true), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitForRoutes(t, advertiser, []string{"10.96.0.10"})
+}
+
+func TestEventsAreDebounced(t *testing.T) {
+	advertiser := &countingAdvertiser{FakeAdvertiser: NewFakeAdvertiser()}
+	_, client := startController(t, testConfig(t, "services:\n  namespaces: [default]\n"), advertiser, 500*time.Millisecond)
+	ctx := context.Background()
+
+	if _, err := client.CoreV1().Services("default").Create(ctx, testService("web", "10.96.0.10"), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.DiscoveryV1().EndpointSlices("default").Create(ctx, testEndpointSlice("web", true), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitForRoutes(t, advertiser.FakeAdvertiser, []string{"10.96.0.10"})
+
+	// A burst of EndpointSlice updates well inside one delay window
+	time.Sleep(100 * time.Millisecond)
+	before := advertiser.reconciles.Load()
+	for i := 0; i < 20; i++ {
+		if _, err := client.DiscoveryV1().EndpointSlices("default").Update(ctx, testEndpointSlice("web", true), metav1.UpdateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(time.Second)
+
+	if got := advertiser.reconciles.Load() - before; got < 1 || got > 2 {
+		t.Errorf("burst of 20 events caused %d reconciles, want 1 or 2", got)
+	}
+}
+
+func TestChangesAreAppliedInOneBatch(t *testing.T) {
+	advertiser := &countingAdvertiser{FakeAdvertiser: NewFakeAdvertiser()}
+	_, client := startController(t, testConfig(t, "services:\n  namespaces: [default]\n"), advertiser, 500*time.Millisecond)
+	ctx := context.Background()
+
+	// Let the initial reconcile pass so that all changes fall into the next
+	waitFor(t, "the initial reconcile", func() bool { return advertiser.reconciles.Load() > 0 })
+	for name, clusterIP := range map[string]string{"web": "10.96.0.10", "api": "10.96.0.11"} {
+		if _, err := client.CoreV1().Services("default").Create(ctx, testService(name, clusterIP), metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.DiscoveryV1().EndpointSlices("default").Create(ctx, testEndpointSlice(name, true), metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	waitForRoutes(t, advertiser.FakeAdvertiser, []string{"10.96.0.10", "10.96.0.11"})
+
+	if got := advertiser.Batches(); got != 1 {
+		t.Errorf("two new services were applied in %d batches, want 1", got)
+	}
+}
+
+func TestAnnotationsSetRouteAttributes(t *testing.T) {
+	advertiser := NewFakeAdvertiser()
+	_, client := startController(t, testConfig(t, "services:\n  namespaces: [default]\n"), advertiser, 50*time.Millisecond)
+	ctx := context.Background()
+
+	service := testService("web", "10.96.0.10")
+	service.Annotations = map[string]string{
+		AnnotationCommunities: "65000:100,no-export",
+		AnnotationLocalPref:   "200",
+	}
+	if _, err := client.CoreV1().Services("default").Create(ctx, service, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.DiscoveryV1().EndpointSlices("default").Create(ctx, testEndpointSlice("web", true), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitForRoutes(t, advertiser, []string{"10.96.0.10"})
+
+	localPref := uint32(200)
+	want := route.NewAttributes([]string{"65000:100", "no-export"}, nil)
+	want.LocalPref = &localPref
+	if got := advertiser.Attributes("10.96.0.10"); !got.Equal(want) {
+		t.Errorf("attributes = %s, want %s", got, want)
+	}
+
+	// Changing an annotation re-announces the route with the new attributes
+	batches := advertiser.Batches()
+	service.Annotations[AnnotationCommunities] = "65000:200"
+	if _, err := client.CoreV1().Services("default").Update(ctx, service, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	want = route.NewAttributes([]string{"65000:200"}, nil)
+	want.LocalPref = &localPref
+	waitFor(t, "the updated attributes", func() bool { return advertiser.Attributes("10.96.0.10").Equal(want) })
+	if got := advertiser.Batches() - batches; got != 1 {
+		t.Errorf("attribute change was applied in %d batches, want 1", got)
+	}
+}
+
+func TestPeersAreConfigured(t *testing.T) {
+	advertiser := NewFakeAdvertiser()
+	startController(t, testConfig(t, `
+bgp:
+  peers:
+    - address: "10.0.0.1"
+      remote_as: 65000
+    - address: "10.0.0.2"
+      remote_as: 65000
+services:
+  namespaces: [default]
+`), advertiser, 50*time.Millisecond)
+
+	want := []config.PeerConfig{
+		{Address: "10.0.0.1", RemoteAS: 65000},
+		{Address: "10.0.0.2", RemoteAS: 65000},
+	}
+	waitFor(t, "the peers to be configured", func() bool { return reflect.DeepEqual(advertiser.Peers(), want) })
+}
+
+func TestPeersAreRestoredAfterBackendLosesThem(t *testing.T) {
+	advertiser := NewFakeAdvertiser()
+	_, client := startController(t, testConfig(t, `
+bgp:
+  managed: true
+  asn: 65001
+  router_id: "10.0.0.11"
+  peers:
+    - address: "10.0.0.1"
+      remote_as: 65000
+services:
+  namespaces: [default]
+`), advertiser, 50*time.Millisecond)
+
+	want := []config.PeerConfig{{Address: "10.0.0.1", RemoteAS: 65000}}
+	waitFor(t, "the peers to be configured", func() bool { return reflect.DeepEqual(advertiser.Peers(), want) })
+
+	// A routing daemon restarting without persisted configuration comes back
+	// without neighbors; the next reconcile applies them again
+	if err := advertiser.Configure(nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CoreV1().Services("default").Create(context.Background(), testService("web", "10.96.0.10"), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the peers to be restored", func() bool { return reflect.DeepEqual(advertiser.Peers(), want) })
+}
+
+func TestShutdownDoesNotWaitForInformerSync(t *testing.T) {
+	t.Setenv("NODE_NAME", testNode)
+	ctx, cancel := context.WithCancel(context.Background())
+	client := fake.NewSimpleClientset(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: testNode}})
+	// Services can never be listed, so the informer caches never sync
+	var lists atomic.Int32
+	client.PrependReactor("list", "services", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		lists.Add(1)
+		return true, nil, fmt.Errorf("the server could not find the requested resource")
+	})
+
+	cfg := testConfig(t, "services:\n  namespaces: [default]\n")
+	c := NewBGPServiceControllerWithClient(cfg, ctx, client, NewFakeAdvertiser(), health.NewChecker())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.Start()
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	waitFor(t, "the informers to start", func() bool { return lists.Load() > 0 })
+
+	// Both return promptly even though the caches never sync
+	reloaded := make(chan error, 1)
+	go func() { reloaded <- c.Reload(cfg) }()
+	select {
+	case err := <-reloaded:
+		var invalid *config.InvalidError
+		if err == nil || errors.As(err, &invalid) {
+			t.Errorf("Reload() during the initial sync = %v, want a transient error", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Reload blocked while the informer caches were syncing")
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		c.Shutdown(context.Background())
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Shutdown blocked while the informer caches were syncing")
+	}
+}
+
+// slowStoppingAdvertiser shuts down in the background, like the gobgp speaker
+type slowStoppingAdvertiser struct {
+	*FakeAdvertiser
+	stopped chan struct{}
+}
+
+func (a *slowStoppingAdvertiser) Stopped() <-chan struct{} {
+	return a.stopped
+}
+
+func TestStartWaitsForAdvertiserToStop(t *testing.T) {
+	t.Setenv("NODE_NAME", testNode)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	advertiser := &slowStoppingAdvertiser{FakeAdvertiser: NewFakeAdvertiser(), stopped: make(chan struct{})}
+	cfg := testConfig(t, "services:\n  namespaces: [default]\n")
+	checker := health.NewChecker()
+	c := NewBGPServiceControllerWithClient(cfg, ctx, fake.NewSimpleClientset(), advertiser, checker)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.Start()
+	}()
+
+	waitFor(t, "the initial reconcile", checker.IsReady)
+	cancel()
+	select {
+	case <-done:
+		t.Fatal("Start returned before the advertiser stopped")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	close(advertiser.stopped)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Start did not return after the advertiser stopped")
+	}
+}
@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"fmt"
+
+	"cosmolet/pkg/crd"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/informers"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// customResourceInformers holds the listers for the cosmolet custom
+// resources, and for the nodes whose entries their status keeps
+type customResourceInformers struct {
+	factory     dynamicinformer.DynamicSharedInformerFactory
+	peers       cache.GenericLister
+	policies    cache.GenericLister
+	nodeFactory informers.SharedInformerFactory
+	nodes       corelisters.NodeLister
+	synced      []cache.InformerSynced
+}
+
+// setupCustomResourceInformers creates the BGPPeer,
+// ServiceAdvertisementPolicy and Node informers
+func (c *BGPServiceController) setupCustomResourceInformers() error {
+	c.crdInformers = &customResourceInformers{
+		factory:     dynamicinformer.NewDynamicSharedInformerFactory(c.dynamicClient, 0),
+		nodeFactory: informers.NewSharedInformerFactory(c.client, 0),
+	}
+
+	var err error
+	if c.crdInformers.peers, err = c.setupCustomResourceInformer(crd.BGPPeerResource); err != nil {
+		return err
+	}
+	if c.crdInformers.policies, err = c.setupCustomResourceInformer(crd.ServiceAdvertisementPolicyResource); err != nil {
+		return err
+	}
+	return c.setupNodeInformer()
+}
+
+// setupNodeInformer creates the informer that tells which nodes still exist,
+// so that status entries of deleted nodes can be dropped. Only names are
+// kept, as every node caches every Node.
+func (c *BGPServiceController) setupNodeInformer() error {
+	informer := c.crdInformers.nodeFactory.Core().V1().Nodes()
+
+	if err := informer.Informer().SetWatchErrorHandlerWithContext(c.watchErrorHandler("watch_nodes")); err != nil {
+		return fmt.Errorf("failed to set node watch error handler: %v", err)
+	}
+	if err := informer.Informer().SetTransform(func(obj interface{}) (interface{}, error) {
+		if node, ok := obj.(*v1.Node); ok {
+			return &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: node.Name, ResourceVersion: node.ResourceVersion}}, nil
+		}
+		return obj, nil
+	}); err != nil {
+		return fmt.Errorf("failed to set node transform: %v", err)
+	}
+
+	c.crdInformers.nodes = informer.Lister()
+	c.crdInformers.synced = append(c.crdInformers.synced, informer.Informer().HasSynced)
+	return nil
+}
+
+// setupCustomResourceInformer creates the informer for one resource and
+// registers its event handlers. Spec changes trigger a reconcile; status
+// updates, including those written by cosmolet itself, do not.
+func (c *BGPServiceController) setupCustomResourceInformer(resource schema.GroupVersionResource) (cache.GenericLister, error) {
+	informer := c.crdInformers.factory.ForResource(resource)
+
+	if err := informer.Informer().SetWatchErrorHandlerWithContext(c.watchErrorHandler("watch_" + resource.Resource)); err != nil {
+		return nil, fmt.Errorf("failed to set %s watch error handler: %v", resource.Resource, err)
+	}
+
+	if _, err := informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) { c.enqueue() },
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			if specChanged(oldObj, newObj) {
+				c.enqueue()
+			}
+		},
+		DeleteFunc: func(obj interface{}) { c.enqueue() },
+	}); err != nil {
+		return nil, fmt.Errorf("failed to register %s handler: %v", resource.Resource, err)
+	}
+
+	c.crdInformers.synced = append(c.crdInformers.synced, informer.Informer().HasSynced)
+	return informer.Lister(), nil
+}
+
+// specChanged reports whether an update changed more than the status, which
+// with a status subresource leaves the generation alone
+func specChanged(oldObj, newObj interface{}) bool {
+	oldMeta, err := meta.Accessor(oldObj)
+	if err != nil {
+		return true
+	}
+	newMeta, err := meta.Accessor(newObj)
+	if err != nil {
+		return true
+	}
+	return oldMeta.GetGeneration() != newMeta.GetGeneration()
+}
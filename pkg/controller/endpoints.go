@@ -0,0 +1,77 @@
+package controller
+
+import (
+	discoveryv1 "k8s.io/api/discovery/v1"
+)
+
+// endpointCounts summarises the endpoints backing a service across all of
+// its EndpointSlices
+type endpointCounts struct {
+	// ready endpoints are serving and not terminating
+	ready int
+	// servingTerminating endpoints are shutting down gracefully but still
+	// accept traffic
+	servingTerminating int
+}
+
+// serviceHealth is the outcome of a service health check
+type serviceHealth struct {
+	// cluster is true if the service has usable endpoints on any node
+	cluster bool
+	// local is true if the service has usable endpoints on this node
+	local bool
+}
+
+// countEndpoints counts ready and serving-but-terminating endpoints, limited
+// to endpoints on nodeName unless it is empty. The same endpoint may briefly
+// appear in more than one slice while the EndpointSlice controller
+// rebalances, so endpoints are deduplicated by address.
+func countEndpoints(slices []*discoveryv1.EndpointSlice, nodeName string) endpointCounts {
+	var counts endpointCounts
+	seen := make(map[string]bool)
+
+	for _, slice := range slices {
+		for _, endpoint := range slice.Endpoints {
+			if len(endpoint.Addresses) == 0 {
+				continue
+			}
+			if nodeName != "" && (endpoint.NodeName == nil || *endpoint.NodeName != nodeName) {
+				continue
+			}
+			key := string(slice.AddressType) + "/" + endpoint.Addresses[0]
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			switch {
+			case isEndpointReady(endpoint.Conditions):
+				counts.ready++
+			case isEndpointServing(endpoint.Conditions) && isEndpointTerminating(endpoint.Conditions):
+				counts.servingTerminating++
+			}
+		}
+	}
+
+	return counts
+}
+
+// isEndpointReady follows the EndpointSlice API: a nil ready condition must
+// be interpreted as ready
+func isEndpointReady(conditions discoveryv1.EndpointConditions) bool {
+	return conditions.Ready == nil || *conditions.Ready
+}
+
+// isEndpointServing falls back to the ready condition when serving is unset,
+// as older EndpointSlice controllers do not populate it
+func isEndpointServing(conditions discoveryv1.EndpointConditions) bool {
+	if conditions.Serving == nil {
+		return isEndpointReady(conditions)
+	}
+	return *conditions.Serving
+}
+
+// isEndpointTerminating reports whether the endpoint is shutting down
+func isEndpointTerminating(conditions discoveryv1.EndpointConditions) bool {
+	return conditions.Terminating != nil && *conditions.Terminating
+}
@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"sort"
+	"sync"
+
+	"cosmolet/pkg/config"
+	"cosmolet/pkg/route"
+)
+
+// FakeAdvertiser is an in-memory RouteAdvertiser for running the controller
+// without a routing daemon, e.g. in tests
+type FakeAdvertiser struct {
+	mu      sync.Mutex
+	routes  map[string]route.Attributes
+	peers   []config.PeerConfig
+	batches int
+
+	// PingErr, ConfigureErr and ApplyErr are returned by the matching
+	// methods when set
+	PingErr      error
+	ConfigureErr error
+	ApplyErr     error
+	// PersistedRoutes is returned by Persisted
+	PersistedRoutes []string
+}
+
+// NewFakeAdvertiser creates an empty fake advertiser
+func NewFakeAdvertiser() *FakeAdvertiser {
+	return &FakeAdvertiser{routes: make(map[string]route.Attributes)}
+}
+
+// Ping returns PingErr
+func (f *FakeAdvertiser) Ping() error {
+	return f.PingErr
+}
+
+// Configure records the peers unless ConfigureErr is set
+func (f *FakeAdvertiser) Configure(peers []config.PeerConfig) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.ConfigureErr != nil {
+		return f.ConfigureErr
+	}
+	f.peers = peers
+	return nil
+}
+
+// Sessions reports every configured peer as established
+func (f *FakeAdvertiser) Sessions() (map[string]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sessions := make(map[string]string, len(f.peers))
+	for _, peer := range f.peers {
+		sessions[peer.Address] = "Established"
+	}
+	return sessions, nil
+}
+
+// Peers returns the peers passed to the last successful Configure
+func (f *FakeAdvertiser) Peers() []config.PeerConfig {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.peers
+}
+
+// Advertised returns a copy of the advertised addresses
+func (f *FakeAdvertiser) Advertised() (map[string]bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	routes := make(map[string]bool, len(f.routes))
+	for ip := range f.routes {
+		routes[ip] = true
+	}
+	return routes, nil
+}
+
+// Apply records the batch unless ApplyErr is set
+func (f *FakeAdvertiser) Apply(advertise []route.Route, withdraw []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.ApplyErr != nil {
+		return f.ApplyErr
+	}
+	for _, r := range advertise {
+		f.routes[r.IP] = r.Attributes
+	}
+	for _, ip := range withdraw {
+		delete(f.routes, ip)
+	}
+	f.batches++
+	return nil
+}
+
+// Persisted returns PersistedRoutes
+func (f *FakeAdvertiser) Persisted() ([]string, error) {
+	return f.PersistedRoutes, nil
+}
+
+// Routes returns the advertised addresses in sorted order
+func (f *FakeAdvertiser) Routes() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	routes := make([]string, 0, len(f.routes))
+	for ip := range f.routes {
+		routes = append(routes, ip)
+	}
+	sort.Strings(routes)
+	return routes
+}
+
+// Attributes returns the attributes of an advertised address
+func (f *FakeAdvertiser) Attributes(ip string) route.Attributes {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.routes[ip]
+}
+
+// Batches returns the number of successful Apply calls
+func (f *FakeAdvertiser) Batches() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.batches
+}
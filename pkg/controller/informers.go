@@ -0,0 +1,340 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"cosmolet/pkg/config"
+	"cosmolet/pkg/metrics"
+
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	discoverylisters "k8s.io/client-go/listers/discovery/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// reconcileKey is the single work item used by the controller. Every event
+// collapses into one full reconcile, which the queue deduplicates.
+const reconcileKey = "reconcile"
+
+// defaultReconcileDelay debounces events: everything that arrives within
+// this window of the first event is handled by one reconcile, so that
+// EndpointSlice churn cannot make the controller reconcile back to back
+const defaultReconcileDelay = time.Second
+
+// namespaceInformers holds the listers for one informer scope: a single
+// configured namespace, or every namespace when watching cluster-wide
+type namespaceInformers struct {
+	factory        informers.SharedInformerFactory
+	services       corelisters.ServiceLister
+	endpointSlices discoverylisters.EndpointSliceLister
+	// namespaces is only set for the cluster-wide scope, to resolve the
+	// namespace selector
+	namespaces corelisters.NamespaceLister
+	synced     []cache.InformerSynced
+
+	// stop shuts the informers down, e.g. when a reload removes the scope
+	stop context.CancelFunc
+}
+
+// setupInformers creates Service and EndpointSlice informers for every
+// configured namespace, or one cluster-wide set plus a Namespace informer
+// when namespaces are selected by label, and registers the event handlers
+func (c *BGPServiceController) setupInformers() error {
+	var err error
+	if c.namespaceSelector, err = c.config.GetNamespaceSelector(); err != nil {
+		return fmt.Errorf("invalid namespace selector: %v", err)
+	}
+	if c.serviceSelector, err = c.config.GetServiceSelector(); err != nil {
+		return fmt.Errorf("invalid service selector: %v", err)
+	}
+
+	c.informers = make(map[string]*namespaceInformers)
+	for _, scope := range informerScopes(c.config) {
+		nsInformers, err := c.setupScopeInformers(scope)
+		if err != nil {
+			return err
+		}
+		c.informers[scope] = nsInformers
+	}
+
+	if c.dynamicClient != nil {
+		return c.setupCustomResourceInformers()
+	}
+	return nil
+}
+
+// setupScopeInformers creates the informers for one namespace, or for all
+// namespaces when scope is metav1.NamespaceAll
+func (c *BGPServiceController) setupScopeInformers(scope string) (*namespaceInformers, error) {
+	factory := informers.NewSharedInformerFactoryWithOptions(c.client, 0, informers.WithNamespace(scope))
+
+	serviceInformer := factory.Core().V1().Services()
+	sliceInformer := factory.Discovery().V1().EndpointSlices()
+
+	if err := serviceInformer.Informer().SetWatchErrorHandlerWithContext(c.watchErrorHandler("watch_services")); err != nil {
+		return nil, fmt.Errorf("failed to set service watch error handler for namespace %s: %v", scopeName(scope), err)
+	}
+	if err := sliceInformer.Informer().SetWatchErrorHandlerWithContext(c.watchErrorHandler("watch_endpointslices")); err != nil {
+		return nil, fmt.Errorf("failed to set endpointslice watch error handler for namespace %s: %v", scopeName(scope), err)
+	}
+
+	if _, err := serviceInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueue() },
+		UpdateFunc: func(oldObj, newObj interface{}) { c.enqueue() },
+		DeleteFunc: func(obj interface{}) { c.enqueue() },
+	}); err != nil {
+		return nil, fmt.Errorf("failed to register service handler for namespace %s: %v", scopeName(scope), err)
+	}
+
+	if _, err := sliceInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: isServiceEndpointSlice,
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj interface{}) { c.enqueue() },
+			UpdateFunc: func(oldObj, newObj interface{}) { c.enqueue() },
+			DeleteFunc: func(obj interface{}) { c.enqueue() },
+		},
+	}); err != nil {
+		return nil, fmt.Errorf("failed to register endpointslice handler for namespace %s: %v", scopeName(scope), err)
+	}
+
+	nsInformers := &namespaceInformers{
+		factory:        factory,
+		services:       serviceInformer.Lister(),
+		endpointSlices: sliceInformer.Lister(),
+		synced: []cache.InformerSynced{
+			serviceInformer.Informer().HasSynced,
+			sliceInformer.Informer().HasSynced,
+		},
+	}
+
+	// Namespaces are only needed to resolve the namespace selector; label
+	// changes may move a namespace in or out of scope
+	if scope == metav1.NamespaceAll {
+		namespaceInformer := factory.Core().V1().Namespaces()
+		if err := namespaceInformer.Informer().SetWatchErrorHandlerWithContext(c.watchErrorHandler("watch_namespaces")); err != nil {
+			return nil, fmt.Errorf("failed to set namespace watch error handler: %v", err)
+		}
+		if _, err := namespaceInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj interface{}) { c.enqueue() },
+			UpdateFunc: func(oldObj, newObj interface{}) { c.enqueue() },
+			DeleteFunc: func(obj interface{}) { c.enqueue() },
+		}); err != nil {
+			return nil, fmt.Errorf("failed to register namespace handler: %v", err)
+		}
+		nsInformers.namespaces = namespaceInformer.Lister()
+		nsInformers.synced = append(nsInformers.synced, namespaceInformer.Informer().HasSynced)
+	}
+
+	return nsInformers, nil
+}
+
+// informerScopes returns the informer scopes a configuration needs: the
+// configured namespaces, or metav1.NamespaceAll when watching cluster-wide
+func informerScopes(cfg *config.Config) []string {
+	if cfg.WatchesAllNamespaces() {
+		return []string{metav1.NamespaceAll}
+	}
+	return cfg.GetNamespaces()
+}
+
+// informersFor returns the informers that cover namespace
+func (c *BGPServiceController) informersFor(namespace string) *namespaceInformers {
+	if nsInformers, ok := c.informers[namespace]; ok {
+		return nsInformers
+	}
+	return c.informers[metav1.NamespaceAll]
+}
+
+// watchedNamespaces returns the namespaces whose services are advertised:
+// the configured list, or the namespaces matching the namespace selector
+func (c *BGPServiceController) watchedNamespaces() ([]string, error) {
+	if !c.config.WatchesAllNamespaces() {
+		return c.config.GetNamespaces(), nil
+	}
+
+	namespaces, err := c.informers[metav1.NamespaceAll].namespaces.List(c.namespaceSelector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %v", err)
+	}
+
+	names := make([]string, 0, len(namespaces))
+	for _, namespace := range namespaces {
+		names = append(names, namespace.Name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// scopeName returns a printable name for an informer scope
+func scopeName(scope string) string {
+	if scope == metav1.NamespaceAll {
+		return "<all>"
+	}
+	return scope
+}
+
+// runInformers sets up and starts the informers for the current
+// configuration and waits for their caches to sync. The lock is only held
+// while they are set up, so that a cache that cannot sync, e.g. for custom
+// resources whose CRDs are not installed, never blocks Shutdown; reloads are
+// refused until the caches have synced.
+func (c *BGPServiceController) runInformers() error {
+	c.mu.Lock()
+	err := c.setupInformers()
+	var synced []cache.InformerSynced
+	if err == nil {
+		synced = c.startInformers()
+	}
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	log.Println("Waiting for informer caches to sync")
+	if !cache.WaitForCacheSync(c.ctx.Done(), synced...) {
+		return fmt.Errorf("timed out waiting for informer caches to sync")
+	}
+	log.Println("Informer caches synced")
+
+	c.mu.Lock()
+	c.synced = true
+	c.mu.Unlock()
+	return nil
+}
+
+// startInformers starts all informers and returns their sync checks
+func (c *BGPServiceController) startInformers() []cache.InformerSynced {
+	var synced []cache.InformerSynced
+	for _, nsInformers := range c.informers {
+		nsInformers.start(c.ctx)
+		synced = append(synced, nsInformers.synced...)
+	}
+	if c.crdInformers != nil {
+		c.crdInformers.factory.Start(c.ctx.Done())
+		c.crdInformers.nodeFactory.Start(c.ctx.Done())
+		synced = append(synced, c.crdInformers.synced...)
+	}
+	return synced
+}
+
+// start runs the informers until parent is cancelled or stop is called
+func (n *namespaceInformers) start(parent context.Context) {
+	ctx, cancel := context.WithCancel(parent)
+	n.stop = cancel
+	n.factory.Start(ctx.Done())
+}
+
+// shutdown stops the informers if they were started
+func (n *namespaceInformers) shutdown() {
+	if n.stop != nil {
+		n.stop()
+	}
+}
+
+// watchErrorHandler counts list/watch failures and fails the Kubernetes API
+// health check before handing them to the default handler, which logs them
+// and lets the reflector back off. The periodic resync clears the check once
+// the API server is reachable again.
+func (c *BGPServiceController) watchErrorHandler(operation string) cache.WatchErrorHandlerWithContext {
+	return func(ctx context.Context, r *cache.Reflector, err error) {
+		metrics.KubernetesAPIErrorsTotal.WithLabelValues(operation).Inc()
+		c.healthChecker.CheckKubernetesAPI(false, err.Error())
+		cache.DefaultWatchErrorHandler(ctx, r, err)
+	}
+}
+
+// isServiceEndpointSlice filters out EndpointSlices not owned by a Service
+func isServiceEndpointSlice(obj interface{}) bool {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	slice, ok := obj.(*discoveryv1.EndpointSlice)
+	if !ok {
+		return false
+	}
+	return slice.Labels[discoveryv1.LabelServiceName] != ""
+}
+
+// enqueue schedules a full reconcile after the debounce delay. A reconcile
+// that is already waiting is not pushed back, so steady churn still gets
+// reconciled once per delay.
+func (c *BGPServiceController) enqueue() {
+	c.queue.AddAfter(reconcileKey, c.reconcileDelay)
+}
+
+// runResync periodically schedules a reconcile as a safety net for missed
+// events and refreshes the Kubernetes API and FRR health checks. The
+// interval follows configuration reloads.
+func (c *BGPServiceController) runResync() {
+	interval := c.loopInterval()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.checkKubernetesAPI(); err != nil {
+				log.Printf("Kubernetes API health check failed: %v", err)
+			}
+			if err := c.checkFRRConnectivity(); err != nil {
+				log.Printf("FRR health check failed: %v", err)
+			}
+			c.enqueue()
+
+			if next := c.loopInterval(); next != interval {
+				interval = next
+				ticker.Reset(interval)
+			}
+		}
+	}
+}
+
+// loopInterval returns the configured resync interval
+func (c *BGPServiceController) loopInterval() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return time.Duration(c.config.GetLoopInterval()) * time.Second
+}
+
+// runWorker processes work items until the queue is shut down
+func (c *BGPServiceController) runWorker() {
+	for c.processNextWorkItem() {
+	}
+}
+
+// processNextWorkItem runs one reconcile and requeues it with backoff on failure
+func (c *BGPServiceController) processNextWorkItem() bool {
+	key, shutdown := c.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer c.queue.Done(key)
+
+	start := time.Now()
+	err := c.runControlLoop()
+	metrics.ReconcileDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		log.Printf("Reconcile failed, retrying: %v", err)
+		metrics.ReconcilesTotal.WithLabelValues("error").Inc()
+		c.queue.AddRateLimited(key)
+		return true
+	}
+
+	metrics.ReconcilesTotal.WithLabelValues("success").Inc()
+	c.queue.Forget(key)
+
+	// Only report ready once the advertised state reflects the cluster
+	if !c.healthChecker.IsReady() {
+		log.Println("First reconcile completed, marking controller ready")
+		c.healthChecker.SetReady(true)
+	}
+	return true
+}
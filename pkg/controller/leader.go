@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"cosmolet/pkg/config"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// isLeader reports whether this instance may advertise routes
+func (c *BGPServiceController) isLeader() bool {
+	return c.leading.Load()
+}
+
+// runLeaderElection campaigns for the configured Lease until the context is
+// cancelled. Gaining or losing leadership triggers a reconcile, which either
+// takes over advertisement or withdraws everything this instance announced.
+func (c *BGPServiceController) runLeaderElection(election config.ElectionConfig) {
+
+	namespace := election.LeaseNamespace
+	if namespace == "" {
+		namespace = os.Getenv("POD_NAMESPACE")
+	}
+	if namespace == "" {
+		namespace = "default"
+	}
+
+	identity := os.Getenv("POD_NAME")
+	if identity == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			log.Printf("Error determining leader election identity: %v", err)
+			return
+		}
+		identity = hostname
+	}
+
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      election.LeaseName,
+			Namespace: namespace,
+		},
+		Client: c.client.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: identity,
+		},
+	}
+
+	log.Printf("Starting leader election for lease %s/%s as %s", namespace, election.LeaseName, identity)
+
+	// RunOrDie returns whenever leadership is lost; keep campaigning so that
+	// this instance can take over again later
+	for {
+		leaderelection.RunOrDie(c.ctx, leaderelection.LeaderElectionConfig{
+			Lock:            lock,
+			ReleaseOnCancel: true,
+			LeaseDuration:   time.Duration(election.LeaseDurationSeconds) * time.Second,
+			RenewDeadline:   time.Duration(election.RenewDeadlineSeconds) * time.Second,
+			RetryPeriod:     time.Duration(election.RetryPeriodSeconds) * time.Second,
+			Callbacks: leaderelection.LeaderCallbacks{
+				OnStartedLeading: func(ctx context.Context) {
+					log.Printf("Acquired leadership, taking over route advertisement")
+					c.leading.Store(true)
+					c.enqueue()
+				},
+				OnStoppedLeading: func() {
+					log.Printf("Lost leadership, withdrawing advertised routes")
+					c.leading.Store(false)
+					c.enqueue()
+				},
+				OnNewLeader: func(current string) {
+					if current != identity {
+						log.Printf("Current leader is %s", current)
+					}
+				},
+			},
+		})
+
+		select {
+		case <-c.ctx.Done():
+			return
+		default:
+		}
+	}
+}
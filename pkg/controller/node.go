@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"cosmolet/pkg/config"
+	"cosmolet/pkg/metrics"
+
+	"gopkg.in/yaml.v2"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Node labels and annotations that override the BGP configuration of the
+// node they are set on. They are read at startup and again on every
+// configuration reload; a changed ASN or router-id still needs a restart.
+const (
+	// NodeASN is the node's ASN, as a label or an annotation
+	NodeASN = "cosmolet.io/asn"
+	// NodeRouterID is the node's router-id, as a label or an annotation
+	NodeRouterID = "cosmolet.io/router-id"
+	// NodePeers is an annotation with a YAML or JSON list of peers in the
+	// bgp.peers format, e.g. [{"address": "10.1.0.1", "remote_as": 65000}]
+	NodePeers = "cosmolet.io/peers"
+)
+
+// getNode fetches the node this instance runs on, or returns nil if the
+// node name is unknown
+func getNode(ctx context.Context, client kubernetes.Interface, nodeName string) (*v1.Node, error) {
+	if nodeName == "" {
+		return nil, nil
+	}
+
+	node, err := client.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		metrics.KubernetesAPIErrorsTotal.WithLabelValues("get_node").Inc()
+		return nil, fmt.Errorf("failed to get node %s: %v", nodeName, err)
+	}
+	return node, nil
+}
+
+// nodeExists reports whether a node is still part of the cluster, from the
+// Node informer that runs alongside the custom resource informers
+func (c *BGPServiceController) nodeExists(name string) bool {
+	_, err := c.crdInformers.nodes.Get(name)
+	return !apierrors.IsNotFound(err)
+}
+
+// configForNode merges the BGP settings for the node from bgp.nodes and the
+// Node object's labels and annotations over the global configuration
+func configForNode(cfg *config.Config, node *v1.Node) (*config.Config, error) {
+	if node == nil {
+		return cfg, nil
+	}
+
+	overrides, err := nodeOverrides(node)
+	if err != nil {
+		return nil, err
+	}
+
+	merged, err := cfg.ForNode(node.Name, overrides)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Using BGP ASN %d with %d peers on node %s", merged.GetBGPASN(), len(merged.BGP.Peers), node.Name)
+	return merged, nil
+}
+
+// nodeOverrides reads the BGP settings from a node's labels and annotations.
+// Annotations take precedence over labels.
+func nodeOverrides(node *v1.Node) (config.NodeConfig, error) {
+	var overrides config.NodeConfig
+
+	if value, ok := nodeValue(node, NodeASN); ok {
+		asn, err := strconv.ParseUint(strings.TrimSpace(value), 10, 32)
+		if err != nil || asn == 0 {
+			return overrides, fmt.Errorf("invalid %s %q on node %s", NodeASN, value, node.Name)
+		}
+		overrides.ASN = int(asn)
+	}
+
+	if value, ok := nodeValue(node, NodeRouterID); ok {
+		overrides.RouterID = strings.TrimSpace(value)
+	}
+
+	if value, ok := node.Annotations[NodePeers]; ok {
+		if err := yaml.Unmarshal([]byte(value), &overrides.Peers); err != nil {
+			return overrides, fmt.Errorf("invalid %s annotation on node %s: %v", NodePeers, node.Name, err)
+		}
+	}
+
+	return overrides, nil
+}
+
+// nodeValue returns the annotation or, failing that, the label named key
+func nodeValue(node *v1.Node, key string) (string, bool) {
+	if value, ok := node.Annotations[key]; ok {
+		return value, true
+	}
+	value, ok := node.Labels[key]
+	return value, ok
+}
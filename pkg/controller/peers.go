@@ -0,0 +1,216 @@
+package controller
+
+import (
+	"log"
+	"net"
+	"reflect"
+	"sort"
+
+	"cosmolet/pkg/config"
+	"cosmolet/pkg/crd"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/util/retry"
+)
+
+// configurePeers applies the configured peers plus the BGPPeers selected for
+// this node whenever that set changes, or when the backend may have lost
+// them. Failures, e.g. while FRR is still starting, are retried on the next
+// reconcile without holding back route advertisement.
+func (c *BGPServiceController) configurePeers() {
+	peers := c.config.BGP.Peers
+	for _, peer := range c.selectedPeers() {
+		if !hasPeer(peers, peer.Spec.Address) {
+			peers = append(peers, peer.PeerConfig())
+		}
+	}
+
+	if c.backendLost.Swap(false) && c.configured {
+		log.Printf("Routing backend was unreachable, applying the BGP configuration again")
+		c.configured = false
+	}
+	if c.configured && !c.peersPresent() {
+		log.Printf("Routing backend lost its BGP peers, applying the BGP configuration again")
+		c.configured = false
+	}
+	if c.configured && reflect.DeepEqual(peers, c.appliedPeers) {
+		return
+	}
+	if err := c.advertiser.Configure(peers); err != nil {
+		log.Printf("Warning: failed to apply BGP configuration: %v", err)
+		return
+	}
+	c.appliedPeers = peers
+	c.configured = true
+}
+
+// peersPresent reports whether the backend still has every applied peer,
+// which it does not after e.g. FRR restarted with runtime persistence.
+// Without managed mode the peers are not cosmolet's to restore.
+func (c *BGPServiceController) peersPresent() bool {
+	if len(c.appliedPeers) == 0 || !c.config.IsBGPManaged() {
+		return true
+	}
+
+	sessions, err := c.advertiser.Sessions()
+	if err != nil {
+		log.Printf("Warning: failed to get BGP session state: %v", err)
+		return false
+	}
+	present := make(map[string]bool, len(sessions))
+	for address := range sessions {
+		present[normalizeIP(address)] = true
+	}
+	for _, peer := range c.appliedPeers {
+		if !present[normalizeIP(peer.Address)] {
+			return false
+		}
+	}
+	return true
+}
+
+// selectedPeers returns the valid BGPPeers whose node selector matches this
+// node, sorted by name
+func (c *BGPServiceController) selectedPeers() []*crd.BGPPeer {
+	if c.crdInformers == nil {
+		return nil
+	}
+
+	objects, err := c.crdInformers.peers.List(labels.Everything())
+	if err != nil {
+		log.Printf("Warning: failed to list BGP peers: %v", err)
+		return nil
+	}
+
+	var peers []*crd.BGPPeer
+	for _, obj := range objects {
+		peer := &crd.BGPPeer{}
+		if err := crd.FromUnstructured(obj, peer); err != nil {
+			log.Printf("Warning: %v", err)
+			continue
+		}
+		if err := peer.PeerConfig().Validate(); err != nil {
+			log.Printf("Warning: ignoring BGPPeer %s: %v", peer.Name, err)
+			continue
+		}
+		selector, err := selectorFor(peer.Spec.NodeSelector)
+		if err != nil {
+			log.Printf("Warning: ignoring BGPPeer %s: invalid node selector: %v", peer.Name, err)
+			continue
+		}
+		if selector.Matches(c.nodeLabels) {
+			peers = append(peers, peer)
+		}
+	}
+
+	sort.Slice(peers, func(i, j int) bool { return peers[i].Name < peers[j].Name })
+	return peers
+}
+
+// updatePeerStatuses records this node's session state on every BGPPeer
+// selected for it
+func (c *BGPServiceController) updatePeerStatuses() {
+	if c.crdInformers == nil || c.nodeName == "" {
+		return
+	}
+
+	peers := c.selectedPeers()
+	if len(peers) == 0 {
+		return
+	}
+
+	sessions, err := c.advertiser.Sessions()
+	if err != nil {
+		log.Printf("Warning: failed to get BGP session state: %v", err)
+		return
+	}
+	states := make(map[string]string, len(sessions))
+	for address, state := range sessions {
+		states[normalizeIP(address)] = state
+	}
+
+	for _, peer := range peers {
+		state, ok := states[normalizeIP(peer.Spec.Address)]
+		if !ok {
+			state = "NotConfigured"
+		}
+		if current := peerNodeStatus(peer, c.nodeName); current != nil && current.State == state {
+			continue
+		}
+		if err := c.updatePeerStatus(peer.Name, state); err != nil {
+			log.Printf("Warning: failed to update status of BGPPeer %s: %v", peer.Name, err)
+		}
+	}
+}
+
+// updatePeerStatus sets this node's entry in the status of a BGPPeer,
+// dropping the entries of nodes that have been deleted
+func (c *BGPServiceController) updatePeerStatus(name, state string) error {
+	client := c.dynamicClient.Resource(crd.BGPPeerResource)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj, err := client.Get(c.ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		peer := &crd.BGPPeer{}
+		if err := crd.FromUnstructured(obj, peer); err != nil {
+			return err
+		}
+
+		status := crd.PeerNodeStatus{Node: c.nodeName, State: state, LastUpdated: metav1.Now()}
+		var nodes []crd.PeerNodeStatus
+		for _, entry := range peer.Status.Nodes {
+			if entry.Node != c.nodeName && c.nodeExists(entry.Node) {
+				nodes = append(nodes, entry)
+			}
+		}
+		nodes = append(nodes, status)
+		sort.Slice(nodes, func(i, j int) bool { return nodes[i].Node < nodes[j].Node })
+		peer.Status.Nodes = nodes
+
+		updated, err := crd.ToUnstructured(peer)
+		if err != nil {
+			return err
+		}
+		_, err = client.UpdateStatus(c.ctx, updated, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// peerNodeStatus returns the status entry of a node, or nil
+func peerNodeStatus(peer *crd.BGPPeer, nodeName string) *crd.PeerNodeStatus {
+	for i := range peer.Status.Nodes {
+		if peer.Status.Nodes[i].Node == nodeName {
+			return &peer.Status.Nodes[i]
+		}
+	}
+	return nil
+}
+
+// hasPeer reports whether peers contains a neighbor with address
+func hasPeer(peers []config.PeerConfig, address string) bool {
+	for _, peer := range peers {
+		if normalizeIP(peer.Address) == normalizeIP(address) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeIP returns the canonical form of an IP address, or the input if
+// it is not one
+func normalizeIP(address string) string {
+	if ip := net.ParseIP(address); ip != nil {
+		return ip.String()
+	}
+	return address
+}
+
+// selectorFor converts a label selector; an empty selector matches everything
+func selectorFor(selector *metav1.LabelSelector) (labels.Selector, error) {
+	if selector == nil {
+		return labels.Everything(), nil
+	}
+	return metav1.LabelSelectorAsSelector(selector)
+}
@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"cosmolet/pkg/crd"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestUpdatePeerStatusPrunesDeletedNodes(t *testing.T) {
+	peer := &crd.BGPPeer{
+		TypeMeta:   metav1.TypeMeta{APIVersion: crd.Group + "/" + crd.Version, Kind: "BGPPeer"},
+		ObjectMeta: metav1.ObjectMeta{Name: "spine"},
+		Spec:       crd.BGPPeerSpec{Address: "10.0.0.1", RemoteAS: 65000},
+		Status: crd.BGPPeerStatus{Nodes: []crd.PeerNodeStatus{
+			{Node: "node-1", State: "Active"},
+			{Node: "node-2", State: "Established"},
+			{Node: "node-3", State: "Established"},
+		}},
+	}
+	obj, err := crd.ToUnstructured(peer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// node-3 has been removed from the cluster
+	nodes := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, name := range []string{"node-1", "node-2"} {
+		if err := nodes.Add(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c := &BGPServiceController{
+		ctx:           context.Background(),
+		nodeName:      "node-1",
+		dynamicClient: dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), obj),
+		crdInformers:  &customResourceInformers{nodes: corelisters.NewNodeLister(nodes)},
+	}
+
+	if err := c.updatePeerStatus("spine", "Established"); err != nil {
+		t.Fatalf("updatePeerStatus() failed: %v", err)
+	}
+
+	updated, err := c.dynamicClient.Resource(crd.BGPPeerResource).Get(c.ctx, "spine", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := crd.FromUnstructured(updated, peer); err != nil {
+		t.Fatal(err)
+	}
+	states := make(map[string]string)
+	for _, entry := range peer.Status.Nodes {
+		states[entry.Node] = entry.State
+	}
+	if want := map[string]string{"node-1": "Established", "node-2": "Established"}; !reflect.DeepEqual(states, want) {
+		t.Errorf("status nodes = %v, want %v", states, want)
+	}
+}
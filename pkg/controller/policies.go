@@ -0,0 +1,157 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+
+	"cosmolet/pkg/crd"
+	"cosmolet/pkg/route"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/util/retry"
+)
+
+// servicePolicy is a ServiceAdvertisementPolicy with its parsed selector
+type servicePolicy struct {
+	policy   *crd.ServiceAdvertisementPolicy
+	selector labels.Selector
+}
+
+// loadPolicies refreshes the ServiceAdvertisementPolicies from the informer
+// cache, grouped by namespace and sorted by name
+func (c *BGPServiceController) loadPolicies() error {
+	c.policies = make(map[string][]servicePolicy)
+	if c.crdInformers == nil {
+		return nil
+	}
+
+	objects, err := c.crdInformers.policies.List(labels.Everything())
+	if err != nil {
+		return fmt.Errorf("failed to list service advertisement policies: %v", err)
+	}
+
+	for _, obj := range objects {
+		policy := &crd.ServiceAdvertisementPolicy{}
+		if err := crd.FromUnstructured(obj, policy); err != nil {
+			log.Printf("Warning: %v", err)
+			continue
+		}
+		selector, err := selectorFor(policy.Spec.ServiceSelector)
+		if err != nil {
+			log.Printf("Warning: ignoring ServiceAdvertisementPolicy %s/%s: invalid service selector: %v", policy.Namespace, policy.Name, err)
+			continue
+		}
+		c.policies[policy.Namespace] = append(c.policies[policy.Namespace], servicePolicy{policy: policy, selector: selector})
+	}
+
+	for _, policies := range c.policies {
+		sort.Slice(policies, func(i, j int) bool { return policies[i].policy.Name < policies[j].policy.Name })
+	}
+	return nil
+}
+
+// policyFor returns the policy applied to a service: the first policy by
+// name in the service's namespace whose selector matches, or nil
+func (c *BGPServiceController) policyFor(service *v1.Service) *crd.ServiceAdvertisementPolicy {
+	for _, entry := range c.policies[service.Namespace] {
+		if entry.selector.Matches(labels.Set(service.Labels)) {
+			return entry.policy
+		}
+	}
+	return nil
+}
+
+// policyKey returns the namespace/name of a policy, or "" for nil
+func policyKey(policy *crd.ServiceAdvertisementPolicy) string {
+	if policy == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s", policy.Namespace, policy.Name)
+}
+
+// updatePolicyStatuses records the prefixes this node advertises under each
+// policy. Policies this node has never reported on are left alone until it
+// advertises something under them.
+func (c *BGPServiceController) updatePolicyStatuses() {
+	if c.crdInformers == nil || c.nodeName == "" {
+		return
+	}
+
+	prefixes := make(map[string][]string)
+	for ip, current := range c.advertised {
+		if current.policy != "" {
+			prefixes[current.policy] = append(prefixes[current.policy], route.HostPrefix(ip))
+		}
+	}
+
+	for _, policies := range c.policies {
+		for _, entry := range policies {
+			key := policyKey(entry.policy)
+			applied := prefixes[key]
+			sort.Strings(applied)
+			if applied == nil {
+				applied = []string{}
+			}
+
+			current := policyNodeStatus(entry.policy, c.nodeName)
+			if current == nil && len(applied) == 0 {
+				continue
+			}
+			if current != nil && reflect.DeepEqual(current.Prefixes, applied) {
+				continue
+			}
+			if err := c.updatePolicyStatus(entry.policy.Namespace, entry.policy.Name, applied); err != nil {
+				log.Printf("Warning: failed to update status of ServiceAdvertisementPolicy %s: %v", key, err)
+			}
+		}
+	}
+}
+
+// updatePolicyStatus sets this node's entry in the status of a
+// ServiceAdvertisementPolicy, dropping the entries of nodes that have been
+// deleted
+func (c *BGPServiceController) updatePolicyStatus(namespace, name string, prefixes []string) error {
+	client := c.dynamicClient.Resource(crd.ServiceAdvertisementPolicyResource).Namespace(namespace)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj, err := client.Get(c.ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		policy := &crd.ServiceAdvertisementPolicy{}
+		if err := crd.FromUnstructured(obj, policy); err != nil {
+			return err
+		}
+
+		status := crd.PolicyNodeStatus{Node: c.nodeName, Prefixes: prefixes, LastUpdated: metav1.Now()}
+		var nodes []crd.PolicyNodeStatus
+		for _, entry := range policy.Status.Nodes {
+			if entry.Node != c.nodeName && c.nodeExists(entry.Node) {
+				nodes = append(nodes, entry)
+			}
+		}
+		nodes = append(nodes, status)
+		sort.Slice(nodes, func(i, j int) bool { return nodes[i].Node < nodes[j].Node })
+		policy.Status.Nodes = nodes
+
+		updated, err := crd.ToUnstructured(policy)
+		if err != nil {
+			return err
+		}
+		_, err = client.UpdateStatus(c.ctx, updated, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// policyNodeStatus returns the status entry of a node, or nil
+func policyNodeStatus(policy *crd.ServiceAdvertisementPolicy, nodeName string) *crd.PolicyNodeStatus {
+	for i := range policy.Status.Nodes {
+		if policy.Status.Nodes[i].Node == nodeName {
+			return &policy.Status.Nodes[i]
+		}
+	}
+	return nil
+}
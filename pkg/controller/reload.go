@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"cosmolet/pkg/config"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// reloadSyncTimeout bounds how long a reload waits for the informers of
+// newly watched namespaces
+const reloadSyncTimeout = 30 * time.Second
+
+// Reload replaces the running configuration. This node's settings are merged
+// as at startup, informers are started for newly watched namespaces and
+// stopped for the ones no longer watched, and a reconcile is scheduled.
+// Settings only read at startup, such as the backend or the ASN, cannot
+// change; if they do, or the configuration is invalid for this node, the
+// reload fails with a config.InvalidError and the current configuration
+// stays in effect. Other errors, e.g. from the API server or while the
+// informers are still syncing at startup, are transient. Reloads must not
+// run concurrently.
+func (c *BGPServiceController) Reload(cfg *config.Config) error {
+	node, err := getNode(c.ctx, c.client, c.nodeName)
+	if err != nil {
+		return err
+	}
+	cfg, err = configForNode(cfg, node)
+	if err != nil {
+		return &config.InvalidError{Err: err}
+	}
+	namespaceSelector, err := cfg.GetNamespaceSelector()
+	if err != nil {
+		return &config.InvalidError{Err: fmt.Errorf("invalid namespace selector: %v", err)}
+	}
+	serviceSelector, err := cfg.GetServiceSelector()
+	if err != nil {
+		return &config.InvalidError{Err: fmt.Errorf("invalid service selector: %v", err)}
+	}
+
+	c.mu.Lock()
+	if changed := c.config.RestartRequired(cfg); len(changed) > 0 {
+		c.mu.Unlock()
+		return &config.InvalidError{Err: fmt.Errorf("changing %s requires a restart", strings.Join(changed, ", "))}
+	}
+	// Before Start, only the configuration needs replacing
+	started := c.informers != nil
+	if started && !c.synced {
+		c.mu.Unlock()
+		return fmt.Errorf("informer caches are still syncing")
+	}
+	var added map[string]*namespaceInformers
+	if started {
+		added, err = c.startNewScopes(cfg)
+	}
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// Wait without the lock, so that reconciles and Shutdown go on meanwhile
+	if err := waitForScopes(c.ctx, added); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if started {
+		for _, nsInformers := range c.swapInformers(cfg, added) {
+			nsInformers.shutdown()
+		}
+	}
+
+	c.config = cfg
+	c.namespaceSelector = namespaceSelector
+	c.serviceSelector = serviceSelector
+	if node != nil {
+		c.nodeLabels = labels.Set(node.Labels)
+	}
+
+	log.Printf("Applied new configuration, monitoring namespaces: %v", informerScopes(cfg))
+	c.enqueue()
+	return nil
+}
+
+// startNewScopes sets up and starts the informers for the scopes cfg watches
+// that have none running yet
+func (c *BGPServiceController) startNewScopes(cfg *config.Config) (map[string]*namespaceInformers, error) {
+	added := make(map[string]*namespaceInformers)
+	for _, scope := range informerScopes(cfg) {
+		if _, ok := c.informers[scope]; ok {
+			continue
+		}
+		nsInformers, err := c.setupScopeInformers(scope)
+		if err != nil {
+			shutdownScopes(added)
+			return nil, err
+		}
+		nsInformers.start(c.ctx)
+		added[scope] = nsInformers
+	}
+	return added, nil
+}
+
+// waitForScopes waits for the caches of newly started informers to sync,
+// stopping them if they do not within reloadSyncTimeout
+func waitForScopes(parent context.Context, added map[string]*namespaceInformers) error {
+	if len(added) == 0 {
+		return nil
+	}
+
+	var synced []cache.InformerSynced
+	for _, nsInformers := range added {
+		synced = append(synced, nsInformers.synced...)
+	}
+	ctx, cancel := context.WithTimeout(parent, reloadSyncTimeout)
+	defer cancel()
+	if !cache.WaitForCacheSync(ctx.Done(), synced...) {
+		shutdownScopes(added)
+		return fmt.Errorf("timed out waiting for informer caches of new namespaces to sync")
+	}
+	return nil
+}
+
+// swapInformers switches to the informers cfg needs, keeping the running
+// ones for unchanged scopes and adding the new ones, and returns the running
+// informers that are no longer needed
+func (c *BGPServiceController) swapInformers(cfg *config.Config, added map[string]*namespaceInformers) []*namespaceInformers {
+	informers := make(map[string]*namespaceInformers)
+	for _, scope := range informerScopes(cfg) {
+		if nsInformers, ok := added[scope]; ok {
+			informers[scope] = nsInformers
+		} else {
+			informers[scope] = c.informers[scope]
+		}
+	}
+
+	var removed []*namespaceInformers
+	for scope, nsInformers := range c.informers {
+		if _, ok := informers[scope]; !ok {
+			removed = append(removed, nsInformers)
+		}
+	}
+	c.informers = informers
+	return removed
+}
+
+// shutdownScopes stops the given informers
+func shutdownScopes(informers map[string]*namespaceInformers) {
+	for _, nsInformers := range informers {
+		nsInformers.shutdown()
+	}
+}
@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"cosmolet/pkg/config"
+	"cosmolet/pkg/metrics"
+	"cosmolet/pkg/route"
+)
+
+// Shutdown stops reconciling and applies the configured shutdown policy to
+// the routes this instance advertises. With the graceful policy, the grace
+// period ends early when ctx is cancelled. It must be called before the
+// controller's context is cancelled, which stops the gobgp speaker.
+func (c *BGPServiceController) Shutdown(ctx context.Context) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Any reconcile still queued would re-advertise what is withdrawn here
+	c.stopped = true
+
+	if len(c.advertised) == 0 {
+		return
+	}
+
+	policy := c.config.GetShutdownPolicy()
+	if policy == config.ShutdownKeep {
+		log.Printf("Keeping %d advertised routes on shutdown", len(c.advertised))
+		return
+	}
+
+	// Apply relies on a fresh view of what the backend announces
+	if _, err := c.advertiser.Advertised(); err != nil {
+		log.Printf("Warning: failed to list advertised routes: %v", err)
+	}
+	if policy == config.ShutdownGraceful {
+		c.drainRoutes(ctx, time.Duration(c.config.GetShutdownGracefulSeconds())*time.Second)
+	}
+	c.withdrawAll()
+}
+
+// drainRoutes re-advertises every route with the graceful-shutdown community
+// and waits for period, or until ctx is cancelled, so that peers prefer other
+// nodes before the routes are withdrawn
+func (c *BGPServiceController) drainRoutes(ctx context.Context, period time.Duration) {
+	routes := make([]route.Route, 0, len(c.advertised))
+	for ip, current := range c.advertised {
+		routes = append(routes, route.Route{IP: ip, Attributes: current.attributes.WithCommunity(route.GracefulShutdown)})
+	}
+	sort.Slice(routes, func(i, j int) bool { return routes[i].IP < routes[j].IP })
+
+	log.Printf("Advertising %d routes with the graceful-shutdown community, withdrawing them in %v", len(routes), period)
+	if err := c.advertiser.Apply(routes, nil); err != nil {
+		log.Printf("Warning: failed to apply the graceful-shutdown community, withdrawing now: %v", err)
+		return
+	}
+
+	timer := time.NewTimer(period)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		log.Printf("Graceful shutdown period cut short")
+	}
+}
+
+// withdrawAll withdraws every route this instance advertises
+func (c *BGPServiceController) withdrawAll() {
+	withdraw := make([]string, 0, len(c.advertised))
+	for ip := range c.advertised {
+		withdraw = append(withdraw, ip)
+	}
+	sort.Strings(withdraw)
+
+	log.Printf("Withdrawing %d routes on shutdown", len(withdraw))
+	if err := c.advertiser.Apply(nil, withdraw); err != nil {
+		metrics.WithdrawalsTotal.WithLabelValues("error").Add(float64(len(withdraw)))
+		log.Printf("Failed to withdraw routes on shutdown: %v", err)
+		return
+	}
+	metrics.WithdrawalsTotal.WithLabelValues("success").Add(float64(len(withdraw)))
+
+	c.advertised = make(map[string]desiredRoute)
+	metrics.AdvertisedPrefixes.Set(0)
+}
@@ -0,0 +1,142 @@
+// pkg/crd/types.go
+package crd
+
+import (
+	"fmt"
+
+	"cosmolet/pkg/config"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Group and Version of the cosmolet custom resources
+const (
+	Group   = "cosmolet.io"
+	Version = "v1alpha1"
+)
+
+var (
+	// BGPPeerResource is the cluster-scoped BGPPeer resource
+	BGPPeerResource = schema.GroupVersionResource{Group: Group, Version: Version, Resource: "bgppeers"}
+	// ServiceAdvertisementPolicyResource is the namespaced
+	// ServiceAdvertisementPolicy resource
+	ServiceAdvertisementPolicyResource = schema.GroupVersionResource{Group: Group, Version: Version, Resource: "serviceadvertisementpolicies"}
+)
+
+// BGPPeer declares a BGP neighbor for the nodes matching its node selector,
+// in addition to the peers in the configuration. Peers are only applied when
+// cosmolet manages the BGP configuration.
+type BGPPeer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   BGPPeerSpec   `json:"spec"`
+	Status BGPPeerStatus `json:"status,omitempty"`
+}
+
+// BGPPeerSpec mirrors the peer configuration. Passwords are not supported
+// here; peers that need one belong in the configuration file.
+type BGPPeerSpec struct {
+	Address          string `json:"address"`
+	RemoteAS         int    `json:"remoteAS"`
+	Port             int    `json:"port,omitempty"`
+	EBGPMultihop     int    `json:"ebgpMultihop,omitempty"`
+	KeepaliveSeconds int    `json:"keepaliveSeconds,omitempty"`
+	HoldTimeSeconds  int    `json:"holdTimeSeconds,omitempty"`
+	BFD              bool   `json:"bfd,omitempty"`
+	// NodeSelector selects the nodes that peer with this neighbor; every
+	// node when empty
+	NodeSelector *metav1.LabelSelector `json:"nodeSelector,omitempty"`
+}
+
+// BGPPeerStatus reports the session state on every node that peers with
+// the neighbor
+type BGPPeerStatus struct {
+	Nodes []PeerNodeStatus `json:"nodes,omitempty"`
+}
+
+// PeerNodeStatus is the session state of a peer on one node
+type PeerNodeStatus struct {
+	Node string `json:"node"`
+	// State is Idle, Connect, Active, OpenSent, OpenConfirm or Established,
+	// or NotConfigured while the node has not added the neighbor
+	State       string      `json:"state"`
+	LastUpdated metav1.Time `json:"lastUpdated"`
+}
+
+// PeerConfig converts the spec into a configured peer
+func (p *BGPPeer) PeerConfig() config.PeerConfig {
+	return config.PeerConfig{
+		Address:          p.Spec.Address,
+		RemoteAS:         p.Spec.RemoteAS,
+		Port:             p.Spec.Port,
+		EBGPMultihop:     p.Spec.EBGPMultihop,
+		KeepaliveSeconds: p.Spec.KeepaliveSeconds,
+		HoldTimeSeconds:  p.Spec.HoldTimeSeconds,
+		BFD:              p.Spec.BFD,
+	}
+}
+
+// ServiceAdvertisementPolicy sets how the services matching its selector in
+// its namespace are advertised. Service annotations take precedence over
+// policies, which take precedence over the configuration.
+type ServiceAdvertisementPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ServiceAdvertisementPolicySpec   `json:"spec"`
+	Status ServiceAdvertisementPolicyStatus `json:"status,omitempty"`
+}
+
+// ServiceAdvertisementPolicySpec holds the advertisement settings. Unset
+// values keep the configured defaults.
+type ServiceAdvertisementPolicySpec struct {
+	// ServiceSelector selects the services in the policy's namespace; every
+	// service when empty
+	ServiceSelector  *metav1.LabelSelector `json:"serviceSelector,omitempty"`
+	Advertise        *bool                 `json:"advertise,omitempty"`
+	AddressTypes     []string              `json:"addressTypes,omitempty"`
+	Communities      []string              `json:"communities,omitempty"`
+	LargeCommunities []string              `json:"largeCommunities,omitempty"`
+	LocalPref        *uint32               `json:"localPref,omitempty"`
+	MED              *uint32               `json:"med,omitempty"`
+	ASPathPrepend    *int                  `json:"asPathPrepend,omitempty"`
+}
+
+// ServiceAdvertisementPolicyStatus reports the prefixes each node advertises
+// under the policy
+type ServiceAdvertisementPolicyStatus struct {
+	Nodes []PolicyNodeStatus `json:"nodes,omitempty"`
+}
+
+// PolicyNodeStatus lists the prefixes one node advertises under a policy
+type PolicyNodeStatus struct {
+	Node        string      `json:"node"`
+	Prefixes    []string    `json:"prefixes"`
+	LastUpdated metav1.Time `json:"lastUpdated"`
+}
+
+// FromUnstructured converts an object from a dynamic informer or client into
+// one of the typed resources
+func FromUnstructured(obj runtime.Object, out interface{}) error {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("unexpected object type %T", obj)
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, out); err != nil {
+		return fmt.Errorf("failed to decode %s %s: %v", u.GetKind(), u.GetName(), err)
+	}
+	return nil
+}
+
+// ToUnstructured converts a typed resource for the dynamic client
+func ToUnstructured(obj interface{}) (*unstructured.Unstructured, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode object: %v", err)
+	}
+	return &unstructured.Unstructured{Object: content}, nil
+}
@@ -0,0 +1,111 @@
+package frr
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+)
+
+// BGPNextHop is one next hop of a path
+type BGPNextHop struct {
+	IP         string `json:"ip"`
+	AFI        string `json:"afi"`
+	Accessible bool   `json:"accessible"`
+	Used       bool   `json:"used"`
+}
+
+// hasLocalNextHop reports whether a path points at this router, which FRR
+// shows as the unspecified address
+func hasLocalNextHop(nextHops []BGPNextHop) bool {
+	for _, nextHop := range nextHops {
+		ip := net.ParseIP(nextHop.IP)
+		if ip != nil && ip.IsUnspecified() {
+			return true
+		}
+	}
+	return false
+}
+
+// BGPTable is the output of "show bgp <afi> unicast json", keyed by prefix
+type BGPTable struct {
+	RouterID string                    `json:"routerId"`
+	LocalAS  int                       `json:"localAS"`
+	Routes   map[string][]BGPTablePath `json:"routes"`
+}
+
+// localPeerID is the peer FRR reports for paths it originates itself, which
+// have no peer address. The table view has no "sourced" flag, so this is how
+// locally sourced paths are told apart from learned ones.
+const localPeerID = "(unspec)"
+
+// BGPTablePath is a path in the table view, which reports best path
+// selection as a plain boolean. The fields used here have been stable since
+// FRR 7.
+type BGPTablePath struct {
+	Valid    bool         `json:"valid"`
+	BestPath bool         `json:"bestpath"`
+	Origin   string       `json:"origin"`
+	PeerID   string       `json:"peerId"`
+	NextHops []BGPNextHop `json:"nexthops"`
+}
+
+// ParseBGPTable decodes the JSON output of a full table dump
+func ParseBGPTable(data []byte) (*BGPTable, error) {
+	var table BGPTable
+	if err := json.Unmarshal(data, &table); err != nil {
+		return nil, fmt.Errorf("failed to parse FRR table JSON: %v", err)
+	}
+	return &table, nil
+}
+
+// LocalHostRoutes returns the IPs of all /32 and /128 prefixes whose best
+// path is originated by a network statement on this router, sorted
+func (t *BGPTable) LocalHostRoutes() []string {
+	var ips []string
+	for prefix, paths := range t.Routes {
+		ip, ipNet, err := net.ParseCIDR(prefix)
+		if err != nil {
+			continue
+		}
+		if ones, bits := ipNet.Mask.Size(); ones != bits {
+			continue
+		}
+		for _, path := range paths {
+			if path.isLocalBest() {
+				ips = append(ips, ip.String())
+				break
+			}
+		}
+	}
+	sort.Strings(ips)
+	return ips
+}
+
+// isLocalBest checks that the path is the valid best path, originated by a
+// network statement (origin IGP, sourced locally) with a local next hop
+func (p *BGPTablePath) isLocalBest() bool {
+	if !p.Valid || !p.BestPath {
+		return false
+	}
+	if p.PeerID != localPeerID || p.Origin != "IGP" {
+		return false
+	}
+	return hasLocalNextHop(p.NextHops)
+}
+
+// BGPNeighbor is one entry of "show bgp neighbors json", keyed by the
+// neighbor address or interface
+type BGPNeighbor struct {
+	RemoteAS int    `json:"remoteAs"`
+	State    string `json:"bgpState"`
+}
+
+// ParseBGPNeighbors decodes the JSON output of "show bgp neighbors json"
+func ParseBGPNeighbors(data []byte) (map[string]BGPNeighbor, error) {
+	neighbors := make(map[string]BGPNeighbor)
+	if err := json.Unmarshal(data, &neighbors); err != nil {
+		return nil, fmt.Errorf("failed to parse FRR neighbors JSON: %v", err)
+	}
+	return neighbors, nil
+}
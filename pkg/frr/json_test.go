@@ -0,0 +1,131 @@
+package frr
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// releases are the FRR versions with fixtures under testdata
+var releases = []string{"frr-7.5", "frr-8.4", "frr-9.1"}
+
+func readFixture(t *testing.T, release, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", release, name))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	return data
+}
+
+func TestLocalHostRoutesFixtures(t *testing.T) {
+	expected := map[string][]string{
+		"show_bgp_ipv4_unicast.json": {"10.96.0.10", "10.96.0.11"},
+		"show_bgp_ipv6_unicast.json": {"fd00:10:96::10"},
+	}
+
+	for _, release := range releases {
+		for name, want := range expected {
+			t.Run(release+"/"+name, func(t *testing.T) {
+				table, err := ParseBGPTable(readFixture(t, release, name))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if table.LocalAS != 65001 || table.RouterID != "10.0.0.11" {
+					t.Errorf("unexpected table header: AS %d, router-id %q", table.LocalAS, table.RouterID)
+				}
+				if got := table.LocalHostRoutes(); !reflect.DeepEqual(got, want) {
+					t.Errorf("LocalHostRoutes() = %v, want %v", got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestParseBGPNeighborsFixtures(t *testing.T) {
+	for _, release := range releases {
+		t.Run(release, func(t *testing.T) {
+			neighbors, err := ParseBGPNeighbors(readFixture(t, release, "show_bgp_neighbors.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := map[string]string{
+				"10.0.0.1": "Established",
+				"10.0.0.2": "Active",
+				"fd00::1":  "Established",
+			}
+			for address, state := range want {
+				neighbor, ok := neighbors[address]
+				if !ok {
+					t.Errorf("neighbor %s missing", address)
+					continue
+				}
+				if neighbor.State != state || neighbor.RemoteAS != 65000 {
+					t.Errorf("neighbor %s = %+v, want state %s and AS 65000", address, neighbor, state)
+				}
+			}
+		})
+	}
+}
+
+// localPath is a locally originated best path that each case below breaks
+// in one way
+const localPath = `{
+  "valid": true,
+  "bestpath": true,
+  "peerId": "(unspec)",
+  "origin": "IGP",
+  "nexthops": [{"ip": "0.0.0.0", "afi": "ipv4", "used": true}]
+}`
+
+func TestLocalHostRoutesRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want []string
+	}{
+		{"local best path", localPath, []string{"10.96.0.10"}},
+		{"not best", strings.Replace(localPath, `"bestpath": true,`, "", 1), nil},
+		{"not valid", strings.Replace(localPath, `"valid": true,`, `"valid": false,`, 1), nil},
+		{"origin incomplete", strings.Replace(localPath, `"IGP"`, `"incomplete"`, 1), nil},
+		{"origin EGP", strings.Replace(localPath, `"IGP"`, `"EGP"`, 1), nil},
+		{"non-local next hop", strings.Replace(localPath, `"0.0.0.0"`, `"10.0.0.1"`, 1), nil},
+		{"learned from a peer", strings.Replace(localPath, `"(unspec)"`, `"10.0.0.1"`, 1), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := `{"routes": {"10.96.0.10/32": [` + tt.path + `]}}`
+			table, err := ParseBGPTable([]byte(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := table.LocalHostRoutes(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LocalHostRoutes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalHostRoutesSkipsNetworks(t *testing.T) {
+	data := `{"routes": {"10.96.0.0/24": [` + localPath + `]}}`
+	table, err := ParseBGPTable([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := table.LocalHostRoutes(); len(got) != 0 {
+		t.Errorf("LocalHostRoutes() = %v, want none", got)
+	}
+}
+
+func TestParseInvalidJSON(t *testing.T) {
+	if _, err := ParseBGPTable([]byte("% BGP instance not found")); err == nil {
+		t.Error("ParseBGPTable accepted invalid JSON")
+	}
+	if _, err := ParseBGPNeighbors([]byte("% BGP instance not found")); err == nil {
+		t.Error("ParseBGPNeighbors accepted invalid JSON")
+	}
+}
@@ -0,0 +1,143 @@
+package frr
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"cosmolet/pkg/config"
+	"cosmolet/pkg/route"
+)
+
+// Persisted returns the service addresses a previous run left behind: the
+// addresses on a dedicated service interface, plus the routes in the include
+// file in include-file persistence mode
+func (a *VtyshAdvertiser) Persisted() ([]string, error) {
+	owned, err := a.addresses.Owned()
+	if err != nil {
+		return nil, err
+	}
+
+	included, err := a.loadIncludeFile()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var ips []string
+	for _, ip := range append(owned, included...) {
+		if !seen[ip] {
+			seen[ip] = true
+			ips = append(ips, ip)
+		}
+	}
+	return ips, nil
+}
+
+// loadIncludeFile reads the routes a previous run wrote to the include file
+// and marks them as owned. Only the include file records which routes
+// cosmolet owns; with the other persistence modes nothing is reported.
+func (a *VtyshAdvertiser) loadIncludeFile() ([]string, error) {
+	if a.config.GetFRRPersistence() != config.PersistenceIncludeFile {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(a.config.FRR.IncludePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read FRR include file %s: %v", a.config.FRR.IncludePath, err)
+	}
+
+	ips := parseNetworks(data)
+	for _, ip := range ips {
+		a.owned[ip] = route.Attributes{}
+	}
+	log.Printf("Loaded %d persisted routes from %s", len(ips), a.config.FRR.IncludePath)
+	return ips, nil
+}
+
+// persist saves the applied changes according to the persistence mode
+func (a *VtyshAdvertiser) persist(advertise []route.Route, withdraw []string) error {
+	for _, r := range advertise {
+		a.owned[r.IP] = r.Attributes
+	}
+	for _, ip := range withdraw {
+		delete(a.owned, ip)
+	}
+
+	switch a.config.GetFRRPersistence() {
+	case config.PersistenceWriteMemory:
+		if output, err := runVtysh("write_memory", "-c", "write memory"); err != nil {
+			return fmt.Errorf("failed to persist config to /etc/frr/frr.conf: %v\nOutput: %s", err, output)
+		}
+		log.Printf("Saved config to /etc/frr/frr.conf")
+	case config.PersistenceIncludeFile:
+		if err := a.writeIncludeFile(); err != nil {
+			return err
+		}
+		log.Printf("Saved %d routes to %s", len(a.owned), a.config.FRR.IncludePath)
+	}
+	return nil
+}
+
+// writeIncludeFile atomically replaces the include file with the managed
+// router configuration, and the route-maps and network statements for every
+// owned route
+func (a *VtyshAdvertiser) writeIncludeFile() error {
+	path := a.config.FRR.IncludePath
+
+	routes := make([]route.Route, 0, len(a.owned))
+	for ip, attributes := range a.owned {
+		routes = append(routes, route.Route{IP: ip, Attributes: attributes})
+	}
+	sort.Slice(routes, func(i, j int) bool { return routes[i].IP < routes[j].IP })
+
+	content := "! Managed by cosmolet, do not edit\n"
+	if a.config.IsBGPManaged() {
+		content += renderRouter(a.config.GetBGPASN(), a.routerID, a.peers, nil)
+	}
+	content += renderNetworks(a.config.GetBGPASN(), routes, nil)
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".cosmolet-*.conf")
+	if err != nil {
+		return fmt.Errorf("failed to create FRR include file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write FRR include file: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write FRR include file: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace FRR include file %s: %v", path, err)
+	}
+	return nil
+}
+
+// parseNetworks returns the addresses of the host route network statements
+// in an include file, with or without a route-map
+func parseNetworks(data []byte) []string {
+	var ips []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if (len(fields) != 2 && len(fields) != 4) || fields[0] != "network" {
+			continue
+		}
+		prefix := fields[1]
+		if i := strings.Index(prefix, "/"); i > 0 {
+			ips = append(ips, prefix[:i])
+		}
+	}
+	return ips
+}
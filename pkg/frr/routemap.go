@@ -0,0 +1,147 @@
+package frr
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"sort"
+	"strings"
+
+	"cosmolet/pkg/route"
+)
+
+// routeMapPrefix starts the name of every route-map cosmolet manages
+const routeMapPrefix = "cosmolet-"
+
+// routeMapName returns the route-map that sets the given attributes. Names
+// are derived from the attributes, so routes with the same attributes share
+// one route-map and a route-map never needs to change once defined.
+func routeMapName(attributes route.Attributes) string {
+	h := fnv.New32a()
+	h.Write([]byte(attributes.String()))
+	return fmt.Sprintf("%s%08x", routeMapPrefix, h.Sum32())
+}
+
+// renderRouteMaps renders one route-map for every distinct attribute set in
+// routes, prepending asn to the AS path where requested. Redefining an
+// existing route-map with the same name is a no-op.
+func renderRouteMaps(asn int, routes []route.Route) string {
+	maps := make(map[string]route.Attributes)
+	for _, r := range routes {
+		if !r.Attributes.IsEmpty() {
+			maps[routeMapName(r.Attributes)] = r.Attributes
+		}
+	}
+
+	names := make([]string, 0, len(maps))
+	for name := range maps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		attributes := maps[name]
+		fmt.Fprintf(&b, "route-map %s permit 10\n", name)
+		if len(attributes.Communities) > 0 {
+			fmt.Fprintf(&b, " set community %s\n", strings.Join(attributes.Communities, " "))
+		}
+		if len(attributes.LargeCommunities) > 0 {
+			fmt.Fprintf(&b, " set large-community %s\n", strings.Join(attributes.LargeCommunities, " "))
+		}
+		if attributes.LocalPref != nil {
+			fmt.Fprintf(&b, " set local-preference %d\n", *attributes.LocalPref)
+		}
+		if attributes.MED != nil {
+			fmt.Fprintf(&b, " set metric %d\n", *attributes.MED)
+		}
+		if attributes.ASPathPrepend > 0 {
+			fmt.Fprintf(&b, " set as-path prepend %s\n", strings.TrimSpace(strings.Repeat(fmt.Sprintf("%d ", asn), attributes.ASPathPrepend)))
+		}
+		b.WriteString("exit\n")
+	}
+	return b.String()
+}
+
+// renderRouteMapRemovals renders the removal of the given route-maps
+func renderRouteMapRemovals(names []string) string {
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "no route-map %s\n", name)
+	}
+	return b.String()
+}
+
+// unusedRouteMaps records the route-maps the batch defines and references,
+// and returns the cosmolet route-maps no network statement references once
+// it is applied. A network statement whose route-map is missing is not
+// announced, so only route-maps unreferenced in the running configuration
+// are removed; if it cannot be read, nothing is.
+func (a *VtyshAdvertiser) unusedRouteMaps(advertise []route.Route, remove []string) []string {
+	if a.routeMaps == nil {
+		if err := a.loadRouteMaps(); err != nil {
+			log.Printf("Warning: not removing unused route-maps: %v", err)
+			return nil
+		}
+	}
+
+	for _, r := range advertise {
+		prefix := route.HostPrefix(r.IP)
+		if r.Attributes.IsEmpty() {
+			delete(a.references, prefix)
+			continue
+		}
+		name := routeMapName(r.Attributes)
+		a.routeMaps[name] = true
+		a.references[prefix] = name
+	}
+	for _, ip := range remove {
+		delete(a.references, route.HostPrefix(ip))
+	}
+
+	used := make(map[string]bool)
+	for _, name := range a.references {
+		used[name] = true
+	}
+	var unused []string
+	for name := range a.routeMaps {
+		if !used[name] {
+			unused = append(unused, name)
+			delete(a.routeMaps, name)
+		}
+	}
+	sort.Strings(unused)
+	return unused
+}
+
+// loadRouteMaps reads the cosmolet route-maps and the route-maps referenced
+// by network statements from the running configuration
+func (a *VtyshAdvertiser) loadRouteMaps() error {
+	output, err := runVtysh("show_running_config", "-c", "show running-config")
+	if err != nil {
+		return fmt.Errorf("failed to read FRR running configuration: %v\nOutput: %s", err, output)
+	}
+	a.routeMaps, a.references = parseRouteMaps(output)
+	return nil
+}
+
+// parseRouteMaps returns the cosmolet route-maps defined in a configuration
+// and the route-map referenced by each network statement, by prefix
+func parseRouteMaps(data []byte) (map[string]bool, map[string]string) {
+	defined := make(map[string]bool)
+	references := make(map[string]string)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		switch {
+		case len(fields) >= 2 && fields[0] == "route-map" && strings.HasPrefix(fields[1], routeMapPrefix):
+			defined[fields[1]] = true
+		case len(fields) == 4 && fields[0] == "network" && fields[2] == "route-map":
+			references[fields[1]] = fields[3]
+		}
+	}
+	return defined, references
+}
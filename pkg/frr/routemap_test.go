@@ -0,0 +1,95 @@
+package frr
+
+import (
+	"reflect"
+	"testing"
+
+	"cosmolet/pkg/route"
+)
+
+func TestParseRouteMaps(t *testing.T) {
+	running := `frr version 9.1
+!
+router bgp 65001
+ address-family ipv4 unicast
+  network 10.96.0.10/32 route-map cosmolet-0000000a
+  network 10.96.0.11/32
+  network 192.168.0.0/24 route-map site-policy
+ exit-address-family
+exit
+!
+route-map cosmolet-0000000a permit 10
+ set community 65001:100
+exit
+!
+route-map cosmolet-0000000b permit 10
+ set community 65001:200
+exit
+!
+route-map site-policy permit 10
+exit
+`
+	defined, references := parseRouteMaps([]byte(running))
+
+	if want := map[string]bool{"cosmolet-0000000a": true, "cosmolet-0000000b": true}; !reflect.DeepEqual(defined, want) {
+		t.Errorf("defined route-maps = %v, want %v", defined, want)
+	}
+	want := map[string]string{"10.96.0.10/32": "cosmolet-0000000a", "192.168.0.0/24": "site-policy"}
+	if !reflect.DeepEqual(references, want) {
+		t.Errorf("references = %v, want %v", references, want)
+	}
+}
+
+func TestUnusedRouteMaps(t *testing.T) {
+	web := route.NewAttributes([]string{"65001:100"}, nil)
+	api := route.NewAttributes([]string{"65001:200"}, nil)
+	a := &VtyshAdvertiser{
+		// A route-map left behind by an earlier run is removed on the first
+		// apply; one referenced by a network cosmolet does not track is kept
+		routeMaps: map[string]bool{"cosmolet-stale": true, "cosmolet-other": true},
+		references: map[string]string{
+			"10.96.0.99/32": "cosmolet-other",
+		},
+	}
+
+	steps := []struct {
+		name      string
+		advertise []route.Route
+		remove    []string
+		want      []string
+	}{
+		{
+			name:      "advertise",
+			advertise: []route.Route{{IP: "10.96.0.10", Attributes: web}, {IP: "10.96.0.11", Attributes: web}},
+			want:      []string{"cosmolet-stale"},
+		},
+		{
+			name:      "attributes change on one route",
+			advertise: []route.Route{{IP: "10.96.0.10", Attributes: api}},
+		},
+		{
+			name:      "attributes change on the other",
+			advertise: []route.Route{{IP: "10.96.0.11", Attributes: route.Attributes{}}},
+			want:      []string{routeMapName(web)},
+		},
+		{
+			name:      "graceful shutdown",
+			advertise: []route.Route{{IP: "10.96.0.10", Attributes: api.WithCommunity(route.GracefulShutdown)}},
+			want:      []string{routeMapName(api)},
+		},
+		{
+			name:   "withdraw",
+			remove: []string{"10.96.0.10", "10.96.0.11"},
+			want:   []string{routeMapName(api.WithCommunity(route.GracefulShutdown))},
+		},
+	}
+
+	for _, step := range steps {
+		if got := a.unusedRouteMaps(step.advertise, step.remove); !reflect.DeepEqual(got, step.want) {
+			t.Errorf("%s: unused route-maps = %v, want %v", step.name, got, step.want)
+		}
+	}
+	if want := map[string]bool{"cosmolet-other": true}; !reflect.DeepEqual(a.routeMaps, want) {
+		t.Errorf("remaining route-maps = %v, want %v", a.routeMaps, want)
+	}
+}
@@ -0,0 +1,136 @@
+package frr
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"strings"
+
+	"cosmolet/pkg/config"
+)
+
+// Configure renders "router bgp", the router-id and the given peers into
+// FRR when cosmolet manages the BGP configuration, and persists them like
+// Apply does. Neighbors that are no longer configured are removed.
+func (a *VtyshAdvertiser) Configure(peers []config.PeerConfig) error {
+	if !a.config.IsBGPEnabled() || !a.config.IsBGPManaged() {
+		return nil
+	}
+
+	neighbors, err := a.Neighbors()
+	if err != nil {
+		return err
+	}
+
+	configured := make(map[string]bool)
+	for _, peer := range peers {
+		configured[net.ParseIP(peer.Address).String()] = true
+	}
+	var stale []string
+	for address := range neighbors {
+		// Interface (unnumbered) neighbors are never managed by cosmolet
+		if ip := net.ParseIP(address); ip != nil && !configured[ip.String()] {
+			stale = append(stale, address)
+		}
+	}
+	sort.Strings(stale)
+
+	log.Printf("Configuring BGP ASN %d with router-id %s and %d peers", a.config.GetBGPASN(), a.routerID, len(peers))
+	if err := applyConfig(renderRouter(a.config.GetBGPASN(), a.routerID, peers, stale)); err != nil {
+		return err
+	}
+	a.peers = peers
+	return a.persist(nil, nil)
+}
+
+// Sessions returns the session state of every neighbor, by address
+func (a *VtyshAdvertiser) Sessions() (map[string]string, error) {
+	neighbors, err := a.Neighbors()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make(map[string]string, len(neighbors))
+	for address, neighbor := range neighbors {
+		sessions[address] = neighbor.State
+	}
+	return sessions, nil
+}
+
+// Neighbors returns the BGP neighbors configured in FRR with their session
+// state
+func (a *VtyshAdvertiser) Neighbors() (map[string]BGPNeighbor, error) {
+	output, err := runVtyshJSON("show_neighbors", "show bgp neighbors json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list BGP neighbors: %v\nOutput: %s", err, output)
+	}
+	return ParseBGPNeighbors(output)
+}
+
+// renderRouter renders the base BGP configuration: router-id, peers and
+// their address families. Options a peer does not set are explicitly
+// removed, so that the rendered configuration converges on every apply.
+func renderRouter(asn int, routerID string, peers []config.PeerConfig, stale []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "router bgp %d\n", asn)
+	fmt.Fprintf(&b, " bgp router-id %s\n", routerID)
+	// Service routes are the only routes cosmolet originates, so eBGP peers
+	// do not need an explicit export policy
+	b.WriteString(" no bgp ebgp-requires-policy\n")
+	b.WriteString(" no bgp default ipv4-unicast\n")
+
+	for _, address := range stale {
+		fmt.Fprintf(&b, " no neighbor %s\n", address)
+	}
+
+	for _, peer := range peers {
+		fmt.Fprintf(&b, " neighbor %s remote-as %d\n", peer.Address, peer.RemoteAS)
+		if peer.Port != 0 {
+			fmt.Fprintf(&b, " neighbor %s port %d\n", peer.Address, peer.Port)
+		} else {
+			fmt.Fprintf(&b, " no neighbor %s port\n", peer.Address)
+		}
+		if peer.Password != "" {
+			fmt.Fprintf(&b, " neighbor %s password %s\n", peer.Address, peer.Password)
+		} else {
+			fmt.Fprintf(&b, " no neighbor %s password\n", peer.Address)
+		}
+		if peer.EBGPMultihop > 0 {
+			fmt.Fprintf(&b, " neighbor %s ebgp-multihop %d\n", peer.Address, peer.EBGPMultihop)
+		} else {
+			fmt.Fprintf(&b, " no neighbor %s ebgp-multihop\n", peer.Address)
+		}
+		if peer.HoldTimeSeconds > 0 {
+			fmt.Fprintf(&b, " neighbor %s timers %d %d\n", peer.Address, peer.KeepaliveSeconds, peer.HoldTimeSeconds)
+		} else {
+			fmt.Fprintf(&b, " no neighbor %s timers\n", peer.Address)
+		}
+		if peer.BFD {
+			fmt.Fprintf(&b, " neighbor %s bfd\n", peer.Address)
+		} else {
+			fmt.Fprintf(&b, " no neighbor %s bfd\n", peer.Address)
+		}
+	}
+
+	// Each peer carries the address family of its session address
+	for _, family := range addressFamilies {
+		var lines []string
+		for _, peer := range peers {
+			if addressFamily(peer.Address) == family {
+				lines = append(lines, fmt.Sprintf("  neighbor %s activate\n", peer.Address))
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, " address-family %s\n", family)
+		for _, line := range lines {
+			b.WriteString(line)
+		}
+		b.WriteString(" exit-address-family\n")
+	}
+
+	b.WriteString("exit\n")
+	return b.String()
+}
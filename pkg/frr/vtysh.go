@@ -0,0 +1,275 @@
+// pkg/frr/vtysh.go
+package frr
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"cosmolet/pkg/config"
+	"cosmolet/pkg/metrics"
+	"cosmolet/pkg/netif"
+	"cosmolet/pkg/route"
+)
+
+// addressFamilies are the FRR address families service routes live in
+var addressFamilies = []string{"ipv4 unicast", "ipv6 unicast"}
+
+// VtyshAdvertiser advertises service addresses by assigning them to the
+// service interface and adding network statements to FRR through vtysh
+type VtyshAdvertiser struct {
+	config    *config.Config
+	addresses *netif.Manager
+	// routerID and peers are rendered into FRR in managed mode
+	routerID string
+	peers    []config.PeerConfig
+
+	// owned holds the routes cosmolet has advertised, written to the include
+	// file in include-file persistence mode
+	owned map[string]route.Attributes
+
+	// routes caches the table read by Advertised for the Apply that follows
+	// it in the same reconcile, so that the table is dumped once per change
+	routes map[string]bool
+
+	// routeMaps holds the cosmolet route-maps defined in FRR and references
+	// the route-map each network statement uses, by prefix. Both are read
+	// from the running configuration on first use and kept up to date by
+	// Apply, which removes route-maps that are no longer referenced.
+	routeMaps  map[string]bool
+	references map[string]string
+}
+
+// NewVtyshAdvertiser creates a vtysh based route advertiser that assigns
+// addresses through the given manager. The router-id is only used when
+// cosmolet manages the BGP configuration.
+func NewVtyshAdvertiser(cfg *config.Config, addresses *netif.Manager, routerID string) *VtyshAdvertiser {
+	return &VtyshAdvertiser{
+		config:    cfg,
+		addresses: addresses,
+		routerID:  routerID,
+		owned:     make(map[string]route.Attributes),
+	}
+}
+
+// Ping tests FRR CLI availability
+func (a *VtyshAdvertiser) Ping() error {
+	_, err := runVtysh("show_version", "-c", "show version")
+	return err
+}
+
+// Advertised returns the addresses that are both on the service interface
+// and announced by FRR as a locally sourced best path
+func (a *VtyshAdvertiser) Advertised() (map[string]bool, error) {
+	assigned, err := a.addresses.List()
+	if err != nil {
+		return nil, err
+	}
+
+	routes, err := a.localRoutes()
+	if err != nil {
+		return nil, err
+	}
+	a.routes = routes
+
+	advertised := make(map[string]bool)
+	for ip := range routes {
+		if assigned[ip] {
+			advertised[ip] = true
+		}
+	}
+	return advertised, nil
+}
+
+// Apply pushes all network statement changes to FRR in a single vtysh
+// session and persists the result once, according to the persistence mode
+func (a *VtyshAdvertiser) Apply(advertise []route.Route, withdraw []string) error {
+	if !a.config.IsBGPEnabled() {
+		log.Printf("BGP is disabled in configuration, skipping %d advertisements and %d withdrawals", len(advertise), len(withdraw))
+		return nil
+	}
+
+	// Only remove networks FRR still announces, so that a route which is
+	// already gone cannot fail the whole batch. The table from the preceding
+	// Advertised call is used once; without one it is read again.
+	routes := a.routes
+	a.routes = nil
+	if routes == nil {
+		var err error
+		if routes, err = a.localRoutes(); err != nil {
+			return err
+		}
+	}
+	var remove []string
+	for _, ip := range withdraw {
+		if routes[ip] {
+			remove = append(remove, ip)
+		}
+	}
+
+	// Assign addresses before announcing them, so that traffic attracted by
+	// a new route is never dropped
+	for _, r := range advertise {
+		if err := a.addresses.Add(r.IP); err != nil {
+			return err
+		}
+	}
+
+	// Route-maps left without a network statement, e.g. after attributes
+	// changed or a graceful shutdown, are removed in the same batch
+	unused := a.unusedRouteMaps(advertise, remove)
+
+	asn := a.config.GetBGPASN()
+	changed := len(advertise) > 0 || len(remove) > 0 || len(unused) > 0
+	if changed {
+		log.Printf("Applying %d advertisements and %d withdrawals via BGP ASN %d, removing %d unused route-maps", len(advertise), len(remove), asn, len(unused))
+		if err := applyConfig(renderNetworks(asn, advertise, remove) + renderRouteMapRemovals(unused)); err != nil {
+			// The batch may have been applied in part, so read the
+			// route-maps again next time
+			a.routeMaps = nil
+			return err
+		}
+	}
+
+	var removeErr error
+	for _, ip := range withdraw {
+		if err := a.addresses.Remove(ip); err != nil && removeErr == nil {
+			removeErr = err
+		}
+	}
+
+	if changed || a.config.GetFRRPersistence() == config.PersistenceIncludeFile {
+		if err := a.persist(advertise, withdraw); err != nil {
+			return err
+		}
+	}
+	if removeErr != nil {
+		return removeErr
+	}
+
+	log.Printf("Successfully applied route changes")
+	return nil
+}
+
+// localRoutes returns the host routes FRR originates from network statements
+func (a *VtyshAdvertiser) localRoutes() (map[string]bool, error) {
+	routes := make(map[string]bool)
+	for _, family := range addressFamilies {
+		output, err := runVtyshJSON("show_routes", fmt.Sprintf("show bgp %s json", family))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s BGP routes: %v\nOutput: %s", family, err, output)
+		}
+
+		table, err := ParseBGPTable(output)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range table.LocalHostRoutes() {
+			routes[ip] = true
+		}
+	}
+	return routes, nil
+}
+
+// renderNetworks renders the configuration that adds and removes the host
+// routes, grouped by address family. Routes with attributes reference a
+// route-map that sets them.
+func renderNetworks(asn int, advertise []route.Route, withdraw []string) string {
+	var b strings.Builder
+	b.WriteString(renderRouteMaps(asn, advertise))
+
+	lines := make(map[string][]string)
+	for _, r := range advertise {
+		line := fmt.Sprintf("  network %s", route.HostPrefix(r.IP))
+		if !r.Attributes.IsEmpty() {
+			line += " route-map " + routeMapName(r.Attributes)
+		}
+		lines[addressFamily(r.IP)] = append(lines[addressFamily(r.IP)], line)
+	}
+	for _, ip := range withdraw {
+		lines[addressFamily(ip)] = append(lines[addressFamily(ip)], fmt.Sprintf("  no network %s", route.HostPrefix(ip)))
+	}
+
+	fmt.Fprintf(&b, "router bgp %d\n", asn)
+	for _, family := range addressFamilies {
+		if len(lines[family]) == 0 {
+			continue
+		}
+		sort.Strings(lines[family])
+		fmt.Fprintf(&b, " address-family %s\n", family)
+		for _, line := range lines[family] {
+			b.WriteString(line + "\n")
+		}
+		b.WriteString(" exit-address-family\n")
+	}
+	b.WriteString("exit\n")
+	return b.String()
+}
+
+// applyConfig loads configuration into the running FRR daemons through a
+// single "vtysh -f" invocation
+func applyConfig(config string) error {
+	file, err := os.CreateTemp("", "cosmolet-*.conf")
+	if err != nil {
+		return fmt.Errorf("failed to create FRR config batch: %v", err)
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.WriteString(config); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write FRR config batch: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write FRR config batch: %v", err)
+	}
+
+	output, err := runVtysh("apply", "-f", file.Name())
+	if err != nil {
+		return fmt.Errorf("failed to apply FRR config batch: %v\nOutput: %s", err, output)
+	}
+	log.Printf("vtysh config batch applied: %s", output)
+	return nil
+}
+
+// runVtysh runs vtysh with the given arguments, recording its latency and
+// failures under operation
+func runVtysh(operation string, args ...string) ([]byte, error) {
+	start := time.Now()
+	output, err := exec.Command("vtysh", args...).CombinedOutput()
+	metrics.VtyshDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.VtyshErrorsTotal.WithLabelValues(operation).Inc()
+	}
+	return output, err
+}
+
+// runVtyshJSON runs a single vtysh show command and returns only its standard
+// output, so that warnings on stderr cannot corrupt the JSON
+func runVtyshJSON(operation, command string) ([]byte, error) {
+	start := time.Now()
+	output, err := exec.Command("vtysh", "-c", command).Output()
+	metrics.VtyshDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.VtyshErrorsTotal.WithLabelValues(operation).Inc()
+	}
+	return output, err
+}
+
+// isIPv6 reports whether ip is an IPv6 address
+func isIPv6(ip string) bool {
+	parsed := net.ParseIP(ip)
+	return parsed != nil && parsed.To4() == nil
+}
+
+// addressFamily returns the FRR address family that carries ip
+func addressFamily(ip string) string {
+	if isIPv6(ip) {
+		return "ipv6 unicast"
+	}
+	return "ipv4 unicast"
+}
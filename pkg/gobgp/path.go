@@ -0,0 +1,148 @@
+package gobgp
+
+import (
+	"fmt"
+	"net"
+
+	"cosmolet/pkg/route"
+
+	api "github.com/osrg/gobgp/v3/api"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// asSequence is the AS_SEQUENCE AS path segment type
+const asSequence = 2
+
+var (
+	ipv4Unicast = &api.Family{Afi: api.Family_AFI_IP, Safi: api.Family_SAFI_UNICAST}
+	ipv6Unicast = &api.Family{Afi: api.Family_AFI_IP6, Safi: api.Family_SAFI_UNICAST}
+)
+
+// familyOf returns the address family and host prefix length for ip
+func familyOf(ip string) (*api.Family, uint32) {
+	parsed := net.ParseIP(ip)
+	if parsed != nil && parsed.To4() == nil {
+		return ipv6Unicast, 128
+	}
+	return ipv4Unicast, 32
+}
+
+// hostPath builds a locally originated path for the host route of ip with
+// the given attributes. The unspecified next hop is rewritten to the session
+// address when announced.
+func hostPath(ip string, attributes route.Attributes, asn uint32) (*api.Path, error) {
+	if net.ParseIP(ip) == nil {
+		return nil, fmt.Errorf("invalid IP address: %s", ip)
+	}
+	family, prefixLen := familyOf(ip)
+
+	nlri, err := anypb.New(&api.IPAddressPrefix{Prefix: ip, PrefixLen: prefixLen})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode NLRI for %s: %v", ip, err)
+	}
+
+	origin, err := anypb.New(&api.OriginAttribute{Origin: 0})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode origin for %s: %v", ip, err)
+	}
+
+	var nextHop *anypb.Any
+	if family == ipv6Unicast {
+		nextHop, err = anypb.New(&api.MpReachNLRIAttribute{
+			Family:   family,
+			NextHops: []string{"::"},
+			Nlris:    []*anypb.Any{nlri},
+		})
+	} else {
+		nextHop, err = anypb.New(&api.NextHopAttribute{NextHop: "0.0.0.0"})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode next hop for %s: %v", ip, err)
+	}
+
+	pattrs := []*anypb.Any{origin, nextHop}
+	extra, err := pathAttributes(attributes, asn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode attributes for %s: %v", ip, err)
+	}
+
+	return &api.Path{
+		Family: family,
+		Nlri:   nlri,
+		Pattrs: append(pattrs, extra...),
+	}, nil
+}
+
+// pathAttributes encodes the attributes of a route as GoBGP attributes,
+// prepending asn to the AS path where requested
+func pathAttributes(attributes route.Attributes, asn uint32) ([]*anypb.Any, error) {
+	var pattrs []*anypb.Any
+
+	if len(attributes.Communities) > 0 {
+		communities := &api.CommunitiesAttribute{}
+		for _, community := range attributes.Communities {
+			value, err := route.CommunityValue(community)
+			if err != nil {
+				return nil, err
+			}
+			communities.Communities = append(communities.Communities, value)
+		}
+		attr, err := anypb.New(communities)
+		if err != nil {
+			return nil, err
+		}
+		pattrs = append(pattrs, attr)
+	}
+
+	if len(attributes.LargeCommunities) > 0 {
+		large := &api.LargeCommunitiesAttribute{}
+		for _, community := range attributes.LargeCommunities {
+			parts, err := route.LargeCommunityValue(community)
+			if err != nil {
+				return nil, err
+			}
+			large.Communities = append(large.Communities, &api.LargeCommunity{
+				GlobalAdmin: parts[0],
+				LocalData1:  parts[1],
+				LocalData2:  parts[2],
+			})
+		}
+		attr, err := anypb.New(large)
+		if err != nil {
+			return nil, err
+		}
+		pattrs = append(pattrs, attr)
+	}
+
+	if attributes.LocalPref != nil {
+		attr, err := anypb.New(&api.LocalPrefAttribute{LocalPref: *attributes.LocalPref})
+		if err != nil {
+			return nil, err
+		}
+		pattrs = append(pattrs, attr)
+	}
+
+	if attributes.MED != nil {
+		attr, err := anypb.New(&api.MultiExitDiscAttribute{Med: *attributes.MED})
+		if err != nil {
+			return nil, err
+		}
+		pattrs = append(pattrs, attr)
+	}
+
+	if attributes.ASPathPrepend > 0 {
+		numbers := make([]uint32, attributes.ASPathPrepend)
+		for i := range numbers {
+			numbers[i] = asn
+		}
+		attr, err := anypb.New(&api.AsPathAttribute{
+			Segments: []*api.AsSegment{{Type: asSequence, Numbers: numbers}},
+		})
+		if err != nil {
+			return nil, err
+		}
+		pattrs = append(pattrs, attr)
+	}
+
+	return pattrs, nil
+}
@@ -0,0 +1,306 @@
+// pkg/gobgp/speaker.go
+package gobgp
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"cosmolet/pkg/config"
+	"cosmolet/pkg/netif"
+	"cosmolet/pkg/route"
+
+	api "github.com/osrg/gobgp/v3/api"
+	"github.com/osrg/gobgp/v3/pkg/server"
+)
+
+// stopTimeout bounds how long the speaker waits to notify peers on shutdown
+const stopTimeout = 5 * time.Second
+
+// sessionStates maps GoBGP session states to the names FRR uses for them,
+// so that session state reads the same with either backend
+var sessionStates = map[api.PeerState_SessionState]string{
+	api.PeerState_IDLE:        "Idle",
+	api.PeerState_CONNECT:     "Connect",
+	api.PeerState_ACTIVE:      "Active",
+	api.PeerState_OPENSENT:    "OpenSent",
+	api.PeerState_OPENCONFIRM: "OpenConfirm",
+	api.PeerState_ESTABLISHED: "Established",
+}
+
+// Speaker is an embedded GoBGP instance that announces service addresses
+// directly to the configured peers, for nodes that do not run FRR
+type Speaker struct {
+	config    *config.Config
+	ctx       context.Context
+	server    *server.BgpServer
+	addresses *netif.Manager
+
+	// paths holds the addresses this speaker has injected into the RIB
+	paths map[string]bool
+	// peers holds the neighbors added to the speaker, by address
+	peers map[string]config.PeerConfig
+
+	// stopped is closed once the speaker has shut down
+	stopped chan struct{}
+}
+
+// NewSpeaker starts a BGP speaker with the given router-id and the ASN from
+// the configuration, assigning addresses through the given manager. Peers
+// are added by Configure. The speaker stops when ctx is cancelled.
+func NewSpeaker(ctx context.Context, cfg *config.Config, addresses *netif.Manager, routerID string) (*Speaker, error) {
+	bgpServer := server.NewBgpServer()
+	go bgpServer.Serve()
+
+	global := &api.Global{
+		Asn:        uint32(cfg.GetBGPASN()),
+		RouterId:   routerID,
+		ListenPort: int32(cfg.BGP.ListenPort),
+	}
+	if err := bgpServer.StartBgp(ctx, &api.StartBgpRequest{Global: global}); err != nil {
+		return nil, fmt.Errorf("failed to start GoBGP speaker: %v", err)
+	}
+	log.Printf("Started GoBGP speaker with ASN %d and router-id %s", global.Asn, global.RouterId)
+
+	s := &Speaker{
+		config:    cfg,
+		ctx:       ctx,
+		server:    bgpServer,
+		addresses: addresses,
+		paths:     make(map[string]bool),
+		peers:     make(map[string]config.PeerConfig),
+		stopped:   make(chan struct{}),
+	}
+
+	go func() {
+		<-ctx.Done()
+		s.stop()
+		close(s.stopped)
+	}()
+
+	return s, nil
+}
+
+// Stopped is closed once the speaker has shut down after its context was
+// cancelled
+func (s *Speaker) Stopped() <-chan struct{} {
+	return s.stopped
+}
+
+// Ping checks that the speaker is running
+func (s *Speaker) Ping() error {
+	_, err := s.server.GetBgp(s.ctx, &api.GetBgpRequest{})
+	return err
+}
+
+// Configure adds, updates and removes neighbors so that the speaker peers
+// with exactly the given peers
+func (s *Speaker) Configure(peers []config.PeerConfig) error {
+	wanted := make(map[string]config.PeerConfig)
+	for _, peer := range peers {
+		wanted[peer.Address] = peer
+	}
+
+	for address, current := range s.peers {
+		if peer, ok := wanted[address]; ok && peer == current {
+			continue
+		}
+		if err := s.server.DeletePeer(s.ctx, &api.DeletePeerRequest{Address: address}); err != nil {
+			return fmt.Errorf("failed to remove BGP peer %s: %v", address, err)
+		}
+		delete(s.peers, address)
+		log.Printf("Removed BGP peer %s", address)
+	}
+
+	for address, peer := range wanted {
+		if _, ok := s.peers[address]; ok {
+			continue
+		}
+		if err := s.server.AddPeer(s.ctx, &api.AddPeerRequest{Peer: peerFromConfig(peer)}); err != nil {
+			return fmt.Errorf("failed to add BGP peer %s: %v", address, err)
+		}
+		if peer.BFD {
+			log.Printf("Warning: BFD is not supported by the gobgp backend, ignoring it for peer %s", address)
+		}
+		s.peers[address] = peer
+		log.Printf("Added BGP peer %s (AS %d)", address, peer.RemoteAS)
+	}
+	return nil
+}
+
+// Sessions returns the session state of every peer, by address
+func (s *Speaker) Sessions() (map[string]string, error) {
+	sessions := make(map[string]string)
+	err := s.server.ListPeer(s.ctx, &api.ListPeerRequest{}, func(peer *api.Peer) {
+		if peer.Conf == nil || peer.State == nil {
+			return
+		}
+		state, ok := sessionStates[peer.State.SessionState]
+		if !ok {
+			state = "Unknown"
+		}
+		sessions[peer.Conf.NeighborAddress] = state
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list GoBGP peers: %v", err)
+	}
+	return sessions, nil
+}
+
+// Advertised returns the addresses injected by this speaker that are on the
+// service interface and still in the global RIB
+func (s *Speaker) Advertised() (map[string]bool, error) {
+	assigned, err := s.addresses.List()
+	if err != nil {
+		return nil, err
+	}
+
+	advertised := make(map[string]bool)
+	for ip := range s.paths {
+		if !assigned[ip] {
+			continue
+		}
+		found, err := s.inRIB(ip)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			advertised[ip] = true
+		}
+	}
+	return advertised, nil
+}
+
+// Apply injects and removes the host routes. Paths are added in-process, so
+// there is no benefit in batching them further.
+func (s *Speaker) Apply(advertise []route.Route, withdraw []string) error {
+	if !s.config.IsBGPEnabled() {
+		log.Printf("BGP is disabled in configuration, skipping %d advertisements and %d withdrawals", len(advertise), len(withdraw))
+		return nil
+	}
+
+	for _, r := range advertise {
+		if err := s.advertise(r); err != nil {
+			return err
+		}
+	}
+	for _, ip := range withdraw {
+		if err := s.withdraw(ip); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Persisted returns the addresses a previous run left on a dedicated service
+// interface. The RIB lives in-process and does not survive a restart.
+func (s *Speaker) Persisted() ([]string, error) {
+	return s.addresses.Owned()
+}
+
+// advertise assigns the address and injects the host route into the RIB,
+// replacing the path and its attributes if it is already there
+func (s *Speaker) advertise(r route.Route) error {
+	path, err := hostPath(r.IP, r.Attributes, uint32(s.config.GetBGPASN()))
+	if err != nil {
+		return err
+	}
+
+	if err := s.addresses.Add(r.IP); err != nil {
+		return err
+	}
+
+	if _, err := s.server.AddPath(s.ctx, &api.AddPathRequest{TableType: api.TableType_GLOBAL, Path: path}); err != nil {
+		return fmt.Errorf("failed to advertise %s via GoBGP: %v", r.IP, err)
+	}
+	s.paths[r.IP] = true
+
+	log.Printf("Successfully advertised %s via GoBGP", route.HostPrefix(r.IP))
+	return nil
+}
+
+// withdraw removes the host route from the RIB and the service interface
+func (s *Speaker) withdraw(ip string) error {
+	path, err := hostPath(ip, route.Attributes{}, uint32(s.config.GetBGPASN()))
+	if err != nil {
+		return err
+	}
+
+	if s.paths[ip] {
+		if err := s.server.DeletePath(s.ctx, &api.DeletePathRequest{TableType: api.TableType_GLOBAL, Path: path}); err != nil {
+			return fmt.Errorf("failed to withdraw %s via GoBGP: %v", ip, err)
+		}
+		delete(s.paths, ip)
+	}
+
+	if err := s.addresses.Remove(ip); err != nil {
+		return err
+	}
+
+	log.Printf("Successfully withdrew %s via GoBGP", route.HostPrefix(ip))
+	return nil
+}
+
+// inRIB checks if the host route for ip is in the speaker's global RIB
+func (s *Speaker) inRIB(ip string) (bool, error) {
+	family, _ := familyOf(ip)
+	found := false
+	err := s.server.ListPath(s.ctx, &api.ListPathRequest{
+		TableType: api.TableType_GLOBAL,
+		Family:    family,
+		Prefixes:  []*api.TableLookupPrefix{{Prefix: route.HostPrefix(ip)}},
+	}, func(destination *api.Destination) {
+		found = found || len(destination.Paths) > 0
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to look up %s in GoBGP RIB: %v", ip, err)
+	}
+	return found, nil
+}
+
+// stop shuts the speaker down, sending notifications to established peers
+func (s *Speaker) stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), stopTimeout)
+	defer cancel()
+
+	if err := s.server.StopBgp(ctx, &api.StopBgpRequest{}); err != nil {
+		log.Printf("Error stopping GoBGP speaker: %v", err)
+		return
+	}
+	log.Println("Stopped GoBGP speaker")
+}
+
+// peerFromConfig converts a configured neighbor into a GoBGP peer with both
+// unicast address families enabled and the configured session options
+func peerFromConfig(peer config.PeerConfig) *api.Peer {
+	port := peer.Port
+	if port == 0 {
+		port = 179
+	}
+
+	p := &api.Peer{
+		Conf: &api.PeerConf{
+			NeighborAddress: peer.Address,
+			PeerAsn:         uint32(peer.RemoteAS),
+			AuthPassword:    peer.Password,
+		},
+		Transport: &api.Transport{
+			RemotePort: uint32(port),
+		},
+		AfiSafis: []*api.AfiSafi{
+			{Config: &api.AfiSafiConfig{Family: ipv4Unicast, Enabled: true}},
+			{Config: &api.AfiSafiConfig{Family: ipv6Unicast, Enabled: true}},
+		},
+	}
+	if peer.EBGPMultihop > 0 {
+		p.EbgpMultihop = &api.EbgpMultihop{Enabled: true, MultihopTtl: uint32(peer.EBGPMultihop)}
+	}
+	if peer.HoldTimeSeconds > 0 {
+		p.Timers = &api.Timers{Config: &api.TimersConfig{
+			HoldTime:          uint64(peer.HoldTimeSeconds),
+			KeepaliveInterval: uint64(peer.KeepaliveSeconds),
+		}}
+	}
+	return p
+}
@@ -0,0 +1,156 @@
+//go:build integration
+
+package gobgp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"cosmolet/pkg/config"
+	"cosmolet/pkg/netif"
+	"cosmolet/pkg/route"
+
+	api "github.com/osrg/gobgp/v3/api"
+	"github.com/osrg/gobgp/v3/pkg/server"
+)
+
+// The speaker under test and its peer both run on loopback. The peer only
+// accepts connections, on peerPort, so the speaker needs no listener.
+const (
+	loopback   = "127.0.0.1"
+	peerPort   = 10179
+	peerASN    = 65000
+	speakerASN = 65001
+	serviceIP  = "192.0.2.10"
+)
+
+// startPeer runs a passive GoBGP instance that accepts a session from the
+// speaker over loopback
+func startPeer(t *testing.T, ctx context.Context) *server.BgpServer {
+	t.Helper()
+	peer := server.NewBgpServer()
+	go peer.Serve()
+
+	err := peer.StartBgp(ctx, &api.StartBgpRequest{Global: &api.Global{
+		Asn:             peerASN,
+		RouterId:        "127.0.0.2",
+		ListenPort:      peerPort,
+		ListenAddresses: []string{loopback},
+	}})
+	if err != nil {
+		t.Fatalf("failed to start peer: %v", err)
+	}
+	t.Cleanup(func() { peer.StopBgp(context.Background(), &api.StopBgpRequest{}) })
+
+	err = peer.AddPeer(ctx, &api.AddPeerRequest{Peer: &api.Peer{
+		Conf:      &api.PeerConf{NeighborAddress: loopback, PeerAsn: speakerASN},
+		Transport: &api.Transport{PassiveMode: true},
+		AfiSafis: []*api.AfiSafi{
+			{Config: &api.AfiSafiConfig{Family: ipv4Unicast, Enabled: true}},
+		},
+	}})
+	if err != nil {
+		t.Fatalf("failed to add speaker to peer: %v", err)
+	}
+	return peer
+}
+
+// received checks if the peer has a path for the host route of ip
+func received(t *testing.T, peer *server.BgpServer, ip string) bool {
+	t.Helper()
+	found := false
+	err := peer.ListPath(context.Background(), &api.ListPathRequest{
+		TableType: api.TableType_GLOBAL,
+		Family:    ipv4Unicast,
+		Prefixes:  []*api.TableLookupPrefix{{Prefix: route.HostPrefix(ip)}},
+	}, func(destination *api.Destination) {
+		found = found || len(destination.Paths) > 0
+	})
+	if err != nil {
+		t.Fatalf("failed to list paths on peer: %v", err)
+	}
+	return found
+}
+
+// waitFor polls until done returns true or fails the test after 30 seconds
+func waitFor(t *testing.T, what string, done func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(30 * time.Second)
+	for !done() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// TestSpeakerLoopbackPeering peers the speaker with a second GoBGP instance
+// and checks that service routes are announced and withdrawn. It assigns
+// addresses to lo and so must run as root:
+//
+//	sudo go test -tags integration -run Loopback ./pkg/gobgp/
+func TestSpeakerLoopbackPeering(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("assigning service addresses to lo requires root")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	peer := startPeer(t, ctx)
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := []byte("bgp:\n  enabled: true\n  backend: gobgp\n  asn: 65001\n  listen_port: -1\n")
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addresses := netif.NewManager("lo")
+	if err := addresses.Ensure(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { addresses.Remove(serviceIP) })
+
+	speaker, err := NewSpeaker(ctx, cfg, addresses, loopback)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := speaker.Ping(); err != nil {
+		t.Fatalf("Ping() failed: %v", err)
+	}
+	if err := speaker.Configure([]config.PeerConfig{{Address: loopback, RemoteAS: peerASN, Port: peerPort}}); err != nil {
+		t.Fatalf("Configure() failed: %v", err)
+	}
+
+	waitFor(t, "the session to be established", func() bool {
+		sessions, err := speaker.Sessions()
+		if err != nil {
+			t.Fatalf("Sessions() failed: %v", err)
+		}
+		return sessions[loopback] == "Established"
+	})
+
+	attributes := route.NewAttributes([]string{"65001:100"}, nil)
+	if err := speaker.Apply([]route.Route{{IP: serviceIP, Attributes: attributes}}, nil); err != nil {
+		t.Fatalf("Apply() failed to advertise: %v", err)
+	}
+	advertised, err := speaker.Advertised()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !advertised[serviceIP] {
+		t.Errorf("Advertised() = %v, want %s", advertised, serviceIP)
+	}
+	waitFor(t, "the peer to receive the route", func() bool { return received(t, peer, serviceIP) })
+
+	if err := speaker.Apply(nil, []string{serviceIP}); err != nil {
+		t.Fatalf("Apply() failed to withdraw: %v", err)
+	}
+	waitFor(t, "the peer to drop the route", func() bool { return !received(t, peer, serviceIP) })
+}
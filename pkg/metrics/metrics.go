@@ -0,0 +1,133 @@
+// pkg/metrics/metrics.go
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "cosmolet"
+
+// Registry holds all cosmolet metrics together with the Go runtime and
+// process collectors
+var Registry = prometheus.NewRegistry()
+
+var (
+	// Info exposes build information as labels on a constant gauge
+	Info = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "info",
+		Help:      "Information about cosmolet",
+	}, []string{"version", "commit"})
+
+	// ReconcileDuration tracks how long each full reconcile takes
+	ReconcileDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "reconcile_duration_seconds",
+		Help:      "Duration of reconcile loops in seconds",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 12),
+	})
+
+	// ReconcilesTotal counts reconciles by result (success or error)
+	ReconcilesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reconciles_total",
+		Help:      "Total number of reconcile loops by result",
+	}, []string{"result"})
+
+	// ServicesDiscovered reports the advertisable services per namespace
+	ServicesDiscovered = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "services_discovered",
+		Help:      "Number of services with advertisable addresses per namespace",
+	}, []string{"namespace"})
+
+	// AdvertisedPrefixes reports the prefixes currently announced by this instance
+	AdvertisedPrefixes = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "advertised_prefixes",
+		Help:      "Number of prefixes currently advertised by this instance",
+	})
+
+	// AdvertisementsTotal counts route advertisements by result
+	AdvertisementsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "advertisements_total",
+		Help:      "Total number of route advertisements by result",
+	}, []string{"result"})
+
+	// WithdrawalsTotal counts route withdrawals by result
+	WithdrawalsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "withdrawals_total",
+		Help:      "Total number of route withdrawals by result",
+	}, []string{"result"})
+
+	// VtyshDuration tracks the latency of vtysh invocations per operation
+	VtyshDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "vtysh_duration_seconds",
+		Help:      "Duration of vtysh invocations in seconds",
+		Buckets:   prometheus.ExponentialBuckets(0.005, 2, 12),
+	}, []string{"operation"})
+
+	// VtyshErrorsTotal counts failed vtysh invocations per operation
+	VtyshErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "vtysh_errors_total",
+		Help:      "Total number of failed vtysh invocations",
+	}, []string{"operation"})
+
+	// KubernetesAPIErrorsTotal counts failed Kubernetes API requests per operation
+	KubernetesAPIErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "kubernetes_api_errors_total",
+		Help:      "Total number of Kubernetes API errors",
+	}, []string{"operation"})
+
+	// ConfigReloadsTotal counts configuration file reloads by result
+	ConfigReloadsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "config_reloads_total",
+		Help:      "Total number of configuration reloads by result",
+	}, []string{"result"})
+
+	// ConfigLastReloadSuccess records when the configuration was last reloaded
+	ConfigLastReloadSuccess = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "config_last_reload_success_timestamp_seconds",
+		Help:      "Timestamp of the last successful configuration reload",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		Info,
+		ReconcileDuration,
+		ReconcilesTotal,
+		ServicesDiscovered,
+		AdvertisedPrefixes,
+		AdvertisementsTotal,
+		WithdrawalsTotal,
+		VtyshDuration,
+		VtyshErrorsTotal,
+		KubernetesAPIErrorsTotal,
+		ConfigReloadsTotal,
+		ConfigLastReloadSuccess,
+	)
+}
+
+// SetBuildInfo records the running version in the info metric
+func SetBuildInfo(version, commit string) {
+	Info.WithLabelValues(version, commit).Set(1)
+}
+
+// Handler returns the HTTP handler serving the registry
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,176 @@
+// pkg/netif/manager.go
+package netif
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"syscall"
+
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// LoopbackInterface is the host loopback device, which is never created
+const LoopbackInterface = "lo"
+
+// ownerAlias is set as the alias of the dummy devices cosmolet creates. Only
+// those are treated as dedicated: an existing dummy device, e.g. kube-proxy's
+// kube-ipvs0, may carry host routes cosmolet must not remove.
+const ownerAlias = "managed-by-cosmolet"
+
+// Manager assigns service addresses to a network interface through netlink.
+// Any interface other than lo is created as a dummy device if missing.
+type Manager struct {
+	name string
+
+	// dedicated is set when the interface is a dummy device cosmolet
+	// created, whose host addresses all belong to cosmolet
+	dedicated bool
+}
+
+// NewManager creates an address manager for the named interface
+func NewManager(name string) *Manager {
+	return &Manager{name: name}
+}
+
+// Name returns the managed interface name
+func (m *Manager) Name() string {
+	return m.name
+}
+
+// Ensure creates the dummy interface if needed, marks it as owned by
+// cosmolet and brings it up
+func (m *Manager) Ensure() error {
+	link, err := netlink.LinkByName(m.name)
+	if err != nil {
+		var notFound netlink.LinkNotFoundError
+		if !errors.As(err, &notFound) || m.name == LoopbackInterface {
+			return fmt.Errorf("failed to get interface %s: %v", m.name, err)
+		}
+
+		log.Printf("Creating dummy interface %s", m.name)
+		dummy := &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: m.name}}
+		if err := netlink.LinkAdd(dummy); err != nil {
+			return fmt.Errorf("failed to create dummy interface %s: %v", m.name, err)
+		}
+		if err := netlink.LinkSetAlias(dummy, ownerAlias); err != nil {
+			return fmt.Errorf("failed to set alias of interface %s: %v", m.name, err)
+		}
+		dummy.Alias = ownerAlias
+		link = dummy
+	}
+
+	if err := netlink.LinkSetUp(link); err != nil {
+		return fmt.Errorf("failed to bring up interface %s: %v", m.name, err)
+	}
+
+	m.dedicated = link.Type() == "dummy" && link.Attrs().Alias == ownerAlias
+	if !m.dedicated {
+		log.Printf("Warning: %s was not created by cosmolet, orphaned service addresses will not be cleaned up", m.name)
+	}
+	return nil
+}
+
+// Owned returns the addresses cosmolet owns on the interface: every host
+// address on a dummy interface cosmolet created, and none on any other
+func (m *Manager) Owned() ([]string, error) {
+	if !m.dedicated {
+		return nil, nil
+	}
+
+	assigned, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+
+	ips := make([]string, 0, len(assigned))
+	for ip := range assigned {
+		ips = append(ips, ip)
+	}
+	sort.Strings(ips)
+	return ips, nil
+}
+
+// List returns the host addresses (/32 and /128) assigned to the interface
+func (m *Manager) List() (map[string]bool, error) {
+	link, err := netlink.LinkByName(m.name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get interface %s: %v", m.name, err)
+	}
+
+	addrs, err := netlink.AddrList(link, netlink.FAMILY_ALL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list addresses on %s: %v", m.name, err)
+	}
+
+	ips := make(map[string]bool)
+	for _, addr := range addrs {
+		if ones, bits := addr.IPNet.Mask.Size(); ones != bits {
+			continue
+		}
+		ips[addr.IP.String()] = true
+	}
+	return ips, nil
+}
+
+// Add assigns the host address for ip. Adding an address that is already
+// present is not an error.
+func (m *Manager) Add(ip string) error {
+	link, addr, err := m.hostAddr(ip)
+	if err != nil {
+		return err
+	}
+
+	if err := netlink.AddrAdd(link, addr); err != nil {
+		if errors.Is(err, syscall.EEXIST) {
+			return nil
+		}
+		return fmt.Errorf("failed to add %s to %s: %v", addr.IPNet, m.name, err)
+	}
+	log.Printf("Added %s to %s", addr.IPNet, m.name)
+	return nil
+}
+
+// Remove deletes the host address for ip. Removing an address that is not
+// present is not an error.
+func (m *Manager) Remove(ip string) error {
+	link, addr, err := m.hostAddr(ip)
+	if err != nil {
+		return err
+	}
+
+	if err := netlink.AddrDel(link, addr); err != nil {
+		if errors.Is(err, syscall.EADDRNOTAVAIL) {
+			return nil
+		}
+		return fmt.Errorf("failed to remove %s from %s: %v", addr.IPNet, m.name, err)
+	}
+	log.Printf("Removed %s from %s", addr.IPNet, m.name)
+	return nil
+}
+
+// hostAddr resolves the interface and builds the /32 or /128 address for ip
+func (m *Manager) hostAddr(ip string) (netlink.Link, *netlink.Addr, error) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return nil, nil, fmt.Errorf("invalid IP address: %s", ip)
+	}
+
+	link, err := netlink.LinkByName(m.name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get interface %s: %v", m.name, err)
+	}
+
+	addr := &netlink.Addr{IPNet: &net.IPNet{IP: parsed, Mask: net.CIDRMask(32, 32)}}
+	if parsed.To4() == nil {
+		addr.IPNet.Mask = net.CIDRMask(128, 128)
+		// Skip duplicate address detection so the address is usable at once
+		addr.Flags = unix.IFA_F_NODAD
+	} else {
+		addr.IPNet.IP = parsed.To4()
+	}
+	return link, addr, nil
+}
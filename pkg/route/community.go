@@ -0,0 +1,88 @@
+package route
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// wellKnownCommunities maps the well-known community names, as spelled by
+// FRR, to their values (RFC 1997, RFC 3765, RFC 7999 and RFC 8326)
+var wellKnownCommunities = map[string]uint32{
+	"no-export":         0xFFFFFF01,
+	"no-advertise":      0xFFFFFF02,
+	"local-AS":          0xFFFFFF03,
+	"no-peer":           0xFFFFFF04,
+	"blackhole":         0xFFFF029A,
+	"graceful-shutdown": 0xFFFF0000,
+}
+
+// ParseCommunity validates a standard community, either "ASN:VALUE" with
+// 16-bit parts or a well-known name, and returns its normalized form
+func ParseCommunity(community string) (string, error) {
+	community = strings.TrimSpace(community)
+	for name := range wellKnownCommunities {
+		if strings.EqualFold(community, name) {
+			return name, nil
+		}
+	}
+
+	parts, err := parseParts(community, 2, 16)
+	if err != nil {
+		return "", fmt.Errorf("invalid community %q: %v", community, err)
+	}
+	return fmt.Sprintf("%d:%d", parts[0], parts[1]), nil
+}
+
+// CommunityValue returns the 32-bit value of a normalized community
+func CommunityValue(community string) (uint32, error) {
+	if value, ok := wellKnownCommunities[community]; ok {
+		return value, nil
+	}
+
+	parts, err := parseParts(community, 2, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid community %q: %v", community, err)
+	}
+	return parts[0]<<16 | parts[1], nil
+}
+
+// ParseLargeCommunity validates a large community "ASN:VALUE1:VALUE2" with
+// 32-bit parts (RFC 8092) and returns its normalized form
+func ParseLargeCommunity(community string) (string, error) {
+	parts, err := LargeCommunityValue(strings.TrimSpace(community))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d:%d:%d", parts[0], parts[1], parts[2]), nil
+}
+
+// LargeCommunityValue returns the three parts of a large community
+func LargeCommunityValue(community string) ([3]uint32, error) {
+	var result [3]uint32
+	parts, err := parseParts(community, 3, 32)
+	if err != nil {
+		return result, fmt.Errorf("invalid large community %q: %v", community, err)
+	}
+	copy(result[:], parts)
+	return result, nil
+}
+
+// parseParts splits a colon separated community into count unsigned
+// integers of the given bit size
+func parseParts(community string, count, bitSize int) ([]uint32, error) {
+	fields := strings.Split(community, ":")
+	if len(fields) != count {
+		return nil, fmt.Errorf("expected %d colon separated values", count)
+	}
+
+	parts := make([]uint32, count)
+	for i, field := range fields {
+		value, err := strconv.ParseUint(field, 10, bitSize)
+		if err != nil {
+			return nil, fmt.Errorf("value %q must be a %d-bit unsigned integer", field, bitSize)
+		}
+		parts[i] = uint32(value)
+	}
+	return parts, nil
+}
@@ -0,0 +1,107 @@
+// pkg/route/route.go
+package route
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// MaxASPathPrepend bounds how many times the local AS may be prepended
+const MaxASPathPrepend = 10
+
+// GracefulShutdown is the well-known community asking peers to lower the
+// preference of routes that are about to be withdrawn (RFC 8326)
+const GracefulShutdown = "graceful-shutdown"
+
+// Route is a service address advertised as a host route, together with the
+// BGP path attributes set on it
+type Route struct {
+	IP         string
+	Attributes Attributes
+}
+
+// HostPrefix returns the host route for ip: /32 for IPv4 and /128 for IPv6
+func HostPrefix(ip string) string {
+	if parsed := net.ParseIP(ip); parsed != nil && parsed.To4() == nil {
+		return ip + "/128"
+	}
+	return ip + "/32"
+}
+
+// Attributes are the BGP path attributes cosmolet sets on a service route.
+// Use NewAttributes so that equal sets compare equal.
+type Attributes struct {
+	Communities      []string
+	LargeCommunities []string
+	// LocalPref and MED are left to the routing daemon when nil
+	LocalPref *uint32
+	MED       *uint32
+	// ASPathPrepend is how many times the local AS is prepended
+	ASPathPrepend int
+}
+
+// NewAttributes returns attributes with the communities sorted and
+// de-duplicated. Values must already be normalized by ParseCommunity and
+// ParseLargeCommunity.
+func NewAttributes(communities, largeCommunities []string) Attributes {
+	return Attributes{
+		Communities:      uniqueSorted(communities),
+		LargeCommunities: uniqueSorted(largeCommunities),
+	}
+}
+
+// WithCommunity returns a copy of the attributes with community added
+func (a Attributes) WithCommunity(community string) Attributes {
+	communities := append(append([]string(nil), a.Communities...), community)
+	a.Communities = uniqueSorted(communities)
+	return a
+}
+
+// IsEmpty reports whether no attributes are set
+func (a Attributes) IsEmpty() bool {
+	return len(a.Communities) == 0 && len(a.LargeCommunities) == 0 &&
+		a.LocalPref == nil && a.MED == nil && a.ASPathPrepend == 0
+}
+
+// Equal reports whether both attribute sets are the same
+func (a Attributes) Equal(other Attributes) bool {
+	return a.String() == other.String()
+}
+
+// String returns a canonical representation of the attributes. Optional
+// attributes only appear when set.
+func (a Attributes) String() string {
+	s := "communities=" + strings.Join(a.Communities, ",") +
+		";large-communities=" + strings.Join(a.LargeCommunities, ",")
+	if a.LocalPref != nil {
+		s += fmt.Sprintf(";local-pref=%d", *a.LocalPref)
+	}
+	if a.MED != nil {
+		s += fmt.Sprintf(";med=%d", *a.MED)
+	}
+	if a.ASPathPrepend > 0 {
+		s += fmt.Sprintf(";as-path-prepend=%d", a.ASPathPrepend)
+	}
+	return s
+}
+
+// uniqueSorted returns the distinct values in sorted order, or nil if there
+// are none
+func uniqueSorted(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var result []string
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	sort.Strings(result)
+	return result
+}